@@ -0,0 +1,42 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+var ErrInvalidCursor = NewValidation("INVALID_CURSOR", "cursor không hợp lệ")
+
+// Cursor is the position of the last row of a page in cursor mode. Order is
+// kept so a cursor is not reused with the opposite sort direction.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Order     string    `json:"o"`
+}
+
+// Encode gives the opaque form of the cursor sent to clients as next_cursor.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor from its opaque form, the order must be the one
+// of the current request.
+func DecodeCursor(value string, order string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Order != order {
+		return nil, ErrInvalidCursor.WithMessage("cursor không khớp với thứ tự sắp xếp")
+	}
+
+	return &cursor, nil
+}
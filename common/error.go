@@ -1,12 +1,159 @@
 package common
 
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
 var ErrBadRequestShouldBind = "Invalid request body"
+
+// ErrorKind classifies an AppError. The error middleware answers with the
+// HTTP status of the kind.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindRateLimited
+)
+
+func (k ErrorKind) HTTPStatus() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// AppError is an error the client can act on. Code is stable and machine
+// readable while Message is meant for people and may change.
+//
+// errors.Is matches two AppErrors with the same Code, so a sentinel like
+// ErrAccountNotFound still matches the copies made by the With methods.
+// FieldErrors lists the failed rules of a validation error.
+type AppError struct {
+	Kind        ErrorKind
+	Code        string
+	Message     string
+	Details     interface{}
+	FieldErrors []FieldError
+	RetryAfter  time.Duration
+	Err         error
+}
+
+func NewNotFound(code, message string) *AppError {
+	return &AppError{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func NewConflict(code, message string) *AppError {
+	return &AppError{Kind: KindConflict, Code: code, Message: message}
+}
+
+func NewUnauthorized(code, message string) *AppError {
+	return &AppError{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func NewForbidden(code, message string) *AppError {
+	return &AppError{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NewValidation(code, message string) *AppError {
+	return &AppError{Kind: KindValidation, Code: code, Message: message}
+}
+
+func NewRateLimited(code, message string) *AppError {
+	return &AppError{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+// ErrInternal is what the client sees of an error that is not an AppError,
+// the error itself is only logged.
+var ErrInternal = &AppError{Kind: KindInternal, Code: "INTERNAL_ERROR", Message: "Internal server error"}
+
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+func (e *AppError) WithMessage(message string) *AppError {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func (e *AppError) WithFieldErrors(fieldErrors []FieldError) *AppError {
+	copied := *e
+	copied.FieldErrors = fieldErrors
+	return &copied
+}
+
+func (e *AppError) WithRetryAfter(retryAfter time.Duration) *AppError {
+	copied := *e
+	copied.RetryAfter = retryAfter
+	return &copied
+}
+
+// Wrap keeps err as the cause, for logs, without showing it to the client.
+func (e *AppError) Wrap(err error) *AppError {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// RetryAfterSeconds rounds RetryAfter up so a client never retries too early.
+func (e *AppError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
 type ResponseError struct {
-	Message string `json:"error"`
+	Message    string       `json:"error"`
+	Code       string       `json:"code,omitempty"`
+	Details    interface{}  `json:"details,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	RetryAfter int          `json:"retry_after,omitempty"`
 }
 
 func NewResponseError(message string) *ResponseError {
 	return &ResponseError{
 		Message: message,
 	}
-}
\ No newline at end of file
+}
+
+func NewResponseAppError(err *AppError) *ResponseError {
+	return &ResponseError{
+		Message:    err.Message,
+		Code:       err.Code,
+		Details:    err.Details,
+		Errors:     err.FieldErrors,
+		RetryAfter: err.RetryAfterSeconds(),
+	}
+}
@@ -0,0 +1,22 @@
+package common
+
+import "time"
+
+// Account statuses of AccountFilter. An account whose temporary lock has
+// ended counts as active.
+const (
+	AccountStatusActive = "active"
+	AccountStatusLocked = "locked"
+)
+
+// AccountFilter holds the query parameters of the admin user list. Empty
+// fields do not filter.
+type AccountFilter struct {
+	Status      string     `form:"status" validate:"omitempty,oneof=active locked"`
+	Role        string     `form:"role" validate:"omitempty,oneof=admin user expert support"`
+	IsVerified  *bool      `form:"is_verified"`
+	CreatedFrom *time.Time `form:"created_from"`
+	CreatedTo   *time.Time `form:"created_to"`
+	// Search matches a part of the email, case-insensitively
+	Search string `form:"search" validate:"omitempty,max=100"`
+}
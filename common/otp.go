@@ -0,0 +1,12 @@
+package common
+
+// OTPPurpose scopes a one-time password to the flow it was issued for, so a
+// code sent for one flow can never be used in another.
+type OTPPurpose string
+
+const (
+	OTPPurposeVerifyEmail   OTPPurpose = "verify-email"
+	OTPPurposeResetPassword OTPPurpose = "reset-password"
+	OTPPurposeLoginStepUp   OTPPurpose = "login-step-up"
+	OTPPurposeEmailChange   OTPPurpose = "email-change"
+)
@@ -1,18 +1,121 @@
 package common
 
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 10
+	// MaxPageLimit caps the limit a client can ask for
+	MaxPageLimit = 100
+)
+
+// The two ways to page through a list. Page mode skips the pages before with
+// OFFSET and counts every row, cursor mode seeks past the last row of the
+// previous page on (created_at, id) and stays fast on large tables.
+const (
+	PagingModePage   = "page"
+	PagingModeCursor = "cursor"
+)
+
+// CursorSort is the only sort of cursor mode, it is the column the cursor
+// seeks on.
+const CursorSort = "created_at"
+
+var ErrInvalidSort = NewValidation("INVALID_SORT", "trường sắp xếp không hợp lệ")
+
+// Paging is read from the query and sent back in the paging envelope. A
+// client starts cursor mode with mode=cursor and gets the next page by
+// sending back next_cursor while has_more is true. Total is only counted in
+// cursor mode when the client asks for it with with_total.
 type Paging struct {
-	Page  int 	`json:"page" form:"page"`
-	Limit int 	`json:"limit" form:"limit"`
-	Total int64 `json:"total" form:"-"`
+	Page       int    `json:"page,omitempty" form:"page"`
+	Limit      int    `json:"limit" form:"limit"`
+	Total      *int64 `json:"total,omitempty" form:"-"`
+	Sort       string `json:"sort,omitempty" form:"sort"`
+	Order      string `json:"order,omitempty" form:"order" validate:"omitempty,oneof=asc desc"`
+	Mode       string `json:"mode,omitempty" form:"mode" validate:"omitempty,oneof=page cursor"`
+	Cursor     string `json:"-" form:"cursor"`
+	WithTotal  bool   `json:"-" form:"with_total"`
+	NextCursor string `json:"next_cursor,omitempty" form:"-"`
+	HasMore    bool   `json:"has_more" form:"-"`
+}
+
+// IsCursorMode reports whether the client asked for cursor mode, sending a
+// cursor is enough.
+func(p *Paging) IsCursorMode() bool {
+	return p.Mode == PagingModeCursor || p.Cursor != ""
 }
 
 func(p *Paging) ProcessPaging() {
-	if p.Page < 1 {
-		p.Page = 1
+	if p.IsCursorMode() {
+		p.Mode = PagingModeCursor
+		p.Page = 0
+	} else {
+		p.Mode = PagingModePage
+		if p.Page < 1 {
+			p.Page = 1
+		}
 	}
 	
 	if p.Limit < 1 {
-		p.Limit = 10
+		p.Limit = DefaultPageLimit
+	}
+
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+}
+
+// ProcessSort checks Sort against the sortable fields of the list and fills
+// in defaultSort, newest first, when the client did not ask for an order.
+// Cursor mode only sorts by CursorSort. Call it after ProcessPaging.
+func(p *Paging) ProcessSort(sortFields []string, defaultSort string) error {
+	if p.IsCursorMode() {
+		if p.Sort != "" && p.Sort != CursorSort {
+			return ErrInvalidSort.WithMessage(fmt.Sprintf("chế độ cursor chỉ có thể sắp xếp theo %s", CursorSort))
+		}
+		sortFields, defaultSort = []string{CursorSort}, CursorSort
 	}
+
+	if p.Sort == "" {
+		p.Sort = defaultSort
+	}
+
+	allowed := false
+	for _, field := range sortFields {
+		if p.Sort == field {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidSort.WithMessage(fmt.Sprintf("chỉ có thể sắp xếp theo %s", strings.Join(sortFields, ", ")))
+	}
+
+	p.Order = strings.ToLower(p.Order)
+	if p.Order == "" {
+		p.Order = "desc"
+	}
+	return nil
+}
+
+// OrderBy is the ORDER BY clause of the sort. Ties are broken by tieBreaker so
+// rows with the same value keep their place between pages. Only use it after
+// ProcessSort has checked the field.
+func(p *Paging) OrderBy(tieBreaker string) string {
+	if p.Sort == tieBreaker {
+		return fmt.Sprintf("%s %s", p.Sort, p.Order)
+	}
+	return fmt.Sprintf("%s %s, %s %s", p.Sort, p.Order, tieBreaker, p.Order)
 }
 
+// SetTotal records the number of rows of the list and, in page mode, whether
+// pages follow the current one.
+func(p *Paging) SetTotal(total int64) {
+	p.Total = &total
+	if !p.IsCursorMode() {
+		p.HasMore = int64(p.Page*p.Limit) < total
+	}
+}
@@ -13,10 +13,40 @@ type ResponseLogin struct {
 	Role		 string `json:"role"`
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	// Only set when two-factor authentication was enabled during this login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
-type ResponseAccessToken struct {
-	AccessToken string `json:"access_token"`
+type ResponseMFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	MFAToken           string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+type ResponseTOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ResponseRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ResponseResetTicket struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+type ResponseOTPSent struct {
+	Email          string `json:"email"`
+	RetryAfter     int    `json:"retry_after"`
+	RemainingToday int    `json:"remaining_today"`
+}
+
+type ResponseTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 func NewResponseNormal(message string, data interface{}) *ResponseNormal {
@@ -35,6 +65,28 @@ func NewResponseLogin(userID, role, accessToken, refreshToken string) *ResponseL
 	}
 }
 
+func NewResponseMFAChallenge(mfaToken string, enrollmentRequired bool, expiresIn int) *ResponseMFAChallenge {
+	return &ResponseMFAChallenge{
+		MFARequired:        true,
+		MFAToken:           mfaToken,
+		EnrollmentRequired: enrollmentRequired,
+		ExpiresIn:          expiresIn,
+	}
+}
+
+func NewResponseTOTPSetup(secret, otpAuthURI string) *ResponseTOTPSetup {
+	return &ResponseTOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: otpAuthURI,
+	}
+}
+
+func NewResponseRecoveryCodes(recoveryCodes []string) *ResponseRecoveryCodes {
+	return &ResponseRecoveryCodes{
+		RecoveryCodes: recoveryCodes,
+	}
+}
+
 func NewResponseRegister(message, email string) *ResponseNormal {
 	return &ResponseNormal{
 		Message: message,
@@ -58,9 +110,25 @@ func NewResponseForgotPassword(message, email string, result bool) *ResponseNorm
 	}
 }
 
-func NewResponseAccessToken(accessToken string) *ResponseAccessToken {
-	return &ResponseAccessToken{
-		AccessToken: accessToken,
+func NewResponseResetTicket(resetToken string, expiresIn int) *ResponseResetTicket {
+	return &ResponseResetTicket{
+		ResetToken: resetToken,
+		ExpiresIn:  expiresIn,
+	}
+}
+
+func NewResponseOTPSent(email string, retryAfter, remainingToday int) *ResponseOTPSent {
+	return &ResponseOTPSent{
+		Email:          email,
+		RetryAfter:     retryAfter,
+		RemainingToday: remainingToday,
+	}
+}
+
+func NewResponseTokens(accessToken, refreshToken string) *ResponseTokens {
+	return &ResponseTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	}
 }
 
@@ -1,13 +1,176 @@
 package common
 
-import "github.com/go-playground/validator/v10"
+import (
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
 
-var validate = validator.New()
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/vi"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	vi_translations "github.com/go-playground/validator/v10/translations/vi"
+)
 
+// Languages of the validation messages, vi unless the client asks for en.
+const (
+	LocaleVI = "vi"
+	LocaleEN = "en"
+)
+
+var (
+	ErrValidationFailed   = NewValidation("VALIDATION_FAILED", "Dữ liệu không hợp lệ")
+	ErrInvalidRequestBody = NewValidation("INVALID_REQUEST_BODY", ErrBadRequestShouldBind)
+)
+
+var validationFailedMessages = map[string]string{
+	LocaleVI: "Dữ liệu không hợp lệ",
+	LocaleEN: "Invalid request",
+}
+
+// FieldError is one failed rule, Field is the JSON name of the field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// customTranslations holds the messages of the validators registered here,
+// the built-in ones come with the validator translations.
+var customTranslations = map[string]map[string]string{
+	"vn_phone": {
+		LocaleVI: "{0} phải là số điện thoại Việt Nam hợp lệ",
+		LocaleEN: "{0} must be a valid Vietnamese phone number",
+	},
+	"past_date": {
+		LocaleVI: "{0} không được là một ngày trong tương lai",
+		LocaleEN: "{0} cannot be in the future",
+	},
+}
+
+var (
+	validate   = validator.New()
+	translator = ut.New(vi.New(), vi.New(), en.New())
+)
+
+func init() {
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	if err := validate.RegisterValidation("vn_phone", validateVietnamesePhone); err != nil {
+		panic(err)
+	}
+	if err := validate.RegisterValidation("past_date", validatePastDate); err != nil {
+		panic(err)
+	}
+
+	viTrans, _ := translator.GetTranslator(LocaleVI)
+	if err := vi_translations.RegisterDefaultTranslations(validate, viTrans); err != nil {
+		panic(err)
+	}
+	enTrans, _ := translator.GetTranslator(LocaleEN)
+	if err := en_translations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		panic(err)
+	}
+
+	for tag, messages := range customTranslations {
+		for locale, message := range messages {
+			trans, _ := translator.GetTranslator(locale)
+			if err := validate.RegisterTranslation(tag, trans, registerMessage(tag, message), translateField); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// ValidateRequest validates the request with the Vietnamese messages.
 func ValidateRequest(request interface{}) error {
+	return ValidateRequestLocale(request, LocaleVI)
+}
+
+// ValidateRequestLocale returns nil or an AppError listing every failed rule
+// with a message in the given locale.
+func ValidateRequestLocale(request interface{}, locale string) error {
 	err := validate.Struct(request)
-	if err != nil {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
 		return err
 	}
-	return nil
-}
\ No newline at end of file
+
+	locale = ParseValidationLocale(locale)
+	trans, _ := translator.GetTranslator(locale)
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+
+	return ErrValidationFailed.
+		WithMessage(validationFailedMessages[locale]).
+		WithFieldErrors(fieldErrors)
+}
+
+// ParseValidationLocale picks vi or en from a locale or an Accept-Language
+// header such as "en-US,en;q=0.9".
+func ParseValidationLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if strings.HasPrefix(locale, LocaleEN) {
+		return LocaleEN
+	}
+	return LocaleVI
+}
+
+// jsonFieldName names fields in errors as the client sends them.
+func jsonFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func validateVietnamesePhone(fl validator.FieldLevel) bool {
+	return utils.IsValidVietnamesePhoneNumber(fl.Field().String())
+}
+
+// validatePastDate accepts today and earlier dates, for dates of birth. A
+// string is read as YYYY-MM-DD, its format is left to the datetime rule.
+func validatePastDate(fl validator.FieldLevel) bool {
+	switch value := fl.Field().Interface().(type) {
+	case time.Time:
+		return !value.After(time.Now())
+	case string:
+		date, err := time.Parse(time.DateOnly, value)
+		return err != nil || !date.After(time.Now())
+	default:
+		return false
+	}
+}
+
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translateField(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,15 +18,32 @@ type Config struct {
 	DBPassword 	string
 	DBName     	string
 	DBTimezone 	string
+	DBAutoMigrate	bool
 	RedisHost  	string
 	RedisPass  	string
+	StoreDriver	string
 	SMTPHost 	string
 	SMTPPort 	string
 	SenderEmail string
 	SenderPass 	string
 	SECRET_KEY 	string
+	OTPHMACKey	string
 	GinPort   	string
+	TrustedProxies	[]string
 	UploadDir	string
+	MFAIssuer			string
+	MFAEncryptionKey	string
+	MFARequiredRoles	[]string
+	JWTKeyDir			string
+	JWTSigningKeyID		string
+	JWTAllowHS256		bool
+	JWTKeyReloadInterval	time.Duration
+	MailDriver			string
+	MailDir				string
+	MailFrom			string
+	EmailWorkerEnabled	bool
+	EmailWorkerInterval	time.Duration
+	EmailMaxAttempts	int
 }
 
 var AppConfig *Config
@@ -40,21 +61,105 @@ func LoadConfig() {
 		DBPassword: getEnv("POSTGRES_PASSWORD", ""),
 		DBName: getEnv("POSTGRES_DB", "test"),
 		DBTimezone: getEnv("POSTGRES_TIMEZONE", "UTC"),
+		// Apply pending migrations at startup, otherwise run "migrate up" before deploying
+		DBAutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
 		RedisHost: getEnv("REDIS_ADDR", ""),
 		RedisPass: getEnv("REDIS_PASSWORD", ""),
+		// redis, or memory for a single instance in local development: revocations,
+		// rate limits and lockouts are then neither shared nor kept on restart
+		StoreDriver: getEnv("STORE_DRIVER", "redis"),
 		SMTPHost: getEnv("SMTP_HOST", ""),
 		SMTPPort: getEnv("SMTP_PORT", ""),
 		SenderEmail: getEnv("SENDER_EMAIL", ""),
 		SenderPass: getEnv("SENDER_PASS", ""),
 		SECRET_KEY: getEnv("JWT_SECRET",""),
+		// Keys the hashes of the OTPs kept in Redis, at least 32 bytes
+		OTPHMACKey: getEnv("OTP_HMAC_KEY", ""),
 		GinPort: getEnv("GIN_PORT", "8080"),
+		// Comma separated IPs or CIDRs of the reverse proxies whose X-Forwarded-For
+		// is believed. Empty trusts none and uses the address of the connection.
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
 		UploadDir: getEnv("UPLOAD_DIR",""),
+		MFAIssuer: getEnv("MFA_ISSUER", "Healthy Service"),
+		// TOTP secrets are encrypted at rest with this key, at least 32 bytes. It
+		// cannot change once accounts have enrolled.
+		MFAEncryptionKey: getEnv("MFA_ENCRYPTION_KEY", ""),
+		// Comma separated roles that must use two-factor authentication, e.g. "admin"
+		MFARequiredRoles: getEnvList("MFA_REQUIRED_ROLES", ""),
+		// Directory of RS256/EdDSA keys named <kid>.pem (private) or <kid>.pub.pem (verify only).
+		// Empty keeps signing with HS256 and JWT_SECRET.
+		JWTKeyDir: getEnv("JWT_KEY_DIR", ""),
+		JWTSigningKeyID: getEnv("JWT_SIGNING_KEY_ID", ""),
+		// Keep accepting HS256 tokens issued before JWT_KEY_DIR was set
+		JWTAllowHS256: getEnvBool("JWT_ALLOW_HS256", false),
+		JWTKeyReloadInterval: getEnvDuration("JWT_KEY_RELOAD_INTERVAL", time.Minute),
+		// smtp, file (writes .eml files to MAIL_DIR) or memory
+		MailDriver: getEnv("MAIL_DRIVER", "smtp"),
+		MailDir: getEnv("MAIL_DIR", "./mails"),
+		MailFrom: getEnv("MAIL_FROM", getEnv("SENDER_EMAIL", "")),
+		// The outbox worker delivers queued emails, several instances can run it at once
+		EmailWorkerEnabled: getEnvBool("EMAIL_WORKER_ENABLED", true),
+		EmailWorkerInterval: getEnvDuration("EMAIL_WORKER_INTERVAL", 5*time.Second),
+		// An email is dead-lettered after this many failed deliveries
+		EmailMaxAttempts: getEnvInt("EMAIL_MAX_ATTEMPTS", 8),
 	}
 }
 
+// minSecretKeyLength is the shortest key accepted for the secrets of Validate.
+const minSecretKeyLength = 32
+
+// Validate reports the settings the API cannot start without.
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.OTPHMACKey) < minSecretKeyLength {
+		errs = append(errs, errors.New("OTP_HMAC_KEY must be set to at least 32 bytes"))
+	}
+	if len(c.MFAEncryptionKey) < minSecretKeyLength {
+		errs = append(errs, errors.New("MFA_ENCRYPTION_KEY must be set to at least 32 bytes"))
+	}
+	return errors.Join(errs...)
+}
+
 func getEnv(key string, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvList(key string, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %t", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %d", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, defaultValue.String()))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %s", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GrantAccessRequest names the expert, by the ID of their login account, who
+// may read the profile and health records of the caller.
+type GrantAccessRequest struct {
+	ExpertAccountID uuid.UUID `json:"expert_account_id" validate:"required"`
+}
+
+type AccessGrantResponse struct {
+	ExpertAccountID uuid.UUID  `json:"expert_account_id"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+}
+
+func NewAccessGrantResponses(grants []*models.AccessGrant) []*AccessGrantResponse {
+	responses := make([]*AccessGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		responses = append(responses, &AccessGrantResponse{
+			ExpertAccountID: grant.GranteeID,
+			CreatedAt:       grant.CreatedAt,
+		})
+	}
+	return responses
+}
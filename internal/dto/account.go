@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegisterAccountRequest is the body of self-registration. Role, verification
+// and status are not part of it, the new account always starts as an
+// unverified user.
+type RegisterAccountRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=100"`
+}
+
+func (r *RegisterAccountRequest) Normalize() {
+	r.Email = common.NormalizeEmail(r.Email)
+}
+
+// ToAccount maps the request to a new account. Password is still in clear
+// text, the service hashes it.
+func (r *RegisterAccountRequest) ToAccount() *models.Account {
+	return &models.Account{
+		Email:         r.Email,
+		Password:      r.Password,
+		Role:          models.RoleUser,
+		IsVerified:    false,
+		AccountStatus: true,
+	}
+}
+
+// CreateAccountRequest is the body of an account created by an administrator.
+// The account is verified. Role names a row of the roles table and defaults
+// to user.
+type CreateAccountRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=100"`
+	Role     string `json:"role,omitempty" validate:"omitempty,max=20"`
+}
+
+func (r *CreateAccountRequest) Normalize() {
+	r.Email = common.NormalizeEmail(r.Email)
+	r.Role = strings.ToLower(strings.TrimSpace(r.Role))
+}
+
+// ToAccount maps the request to a new account. Password is still in clear
+// text, the service hashes it.
+func (r *CreateAccountRequest) ToAccount() *models.Account {
+	role := r.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	return &models.Account{
+		Email:         r.Email,
+		Password:      r.Password,
+		Role:          role,
+		IsVerified:    true,
+		AccountStatus: true,
+	}
+}
+
+// AccountResponse is an account as the API shows it, without the password
+// hash and the TOTP secret.
+type AccountResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	IsVerified    bool       `json:"is_verified"`
+	AccountStatus bool       `json:"account_status"`
+	LockReason    *string    `json:"lock_reason,omitempty"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	TOTPEnabled   bool       `json:"totp_enabled"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at,omitempty"`
+	Locale        string     `json:"locale"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+func NewAccountResponse(account *models.Account) *AccountResponse {
+	return &AccountResponse{
+		ID:            account.ID,
+		Email:         account.Email,
+		Role:          account.Role,
+		IsVerified:    account.IsVerified,
+		AccountStatus: account.AccountStatus,
+		LockReason:    account.LockReason,
+		LockedUntil:   account.LockedUntil,
+		TOTPEnabled:   account.TOTPEnabled,
+		TOTPEnabledAt: account.TOTPEnabledAt,
+		Locale:        account.Locale,
+		CreatedAt:     account.CreatedAt,
+	}
+}
+
+func NewAccountResponses(accounts []*models.Account) []*AccountResponse {
+	responses := make([]*AccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		responses = append(responses, NewAccountResponse(account))
+	}
+	return responses
+}
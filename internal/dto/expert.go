@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateExpertRequest is the metadata of a new expert. Verification, deletion
+// and the login account are set by the service.
+type CreateExpertRequest struct {
+	FullName        string     `json:"full_name" validate:"required,max=255"`
+	DateOfBirth     *time.Time `json:"date_of_birth" validate:"required,past_date"`
+	Gender          bool       `json:"gender"`
+	TelephoneNumber string     `json:"telephone_number" validate:"omitempty,vn_phone"`
+	Email           string     `json:"email" validate:"required,email"`
+}
+
+func (r *CreateExpertRequest) Normalize() {
+	r.Email = common.NormalizeEmail(r.Email)
+}
+
+func (r *CreateExpertRequest) ToExpert(avatarURL string) *models.Expert {
+	return &models.Expert{
+		FullName:        r.FullName,
+		DateOfBirth:     r.DateOfBirth,
+		Gender:          r.Gender,
+		TelephoneNumber: r.TelephoneNumber,
+		Email:           r.Email,
+		AvatarURL:       avatarURL,
+		Verified:        true,
+	}
+}
+
+type ExpertResponse struct {
+	ExpertID        int        `json:"expert_id"`
+	FullName        string     `json:"full_name"`
+	DateOfBirth     *time.Time `json:"date_of_birth"`
+	Gender          bool       `json:"gender"`
+	TelephoneNumber string     `json:"telephone_number,omitempty"`
+	Email           string     `json:"email"`
+	AvatarURL       string     `json:"avatar_url,omitempty"`
+	Verified        bool       `json:"verified"`
+	AccountID       *uuid.UUID `json:"account_id,omitempty"`
+}
+
+func NewExpertResponse(expert *models.Expert) *ExpertResponse {
+	return &ExpertResponse{
+		ExpertID:        expert.ExpertID,
+		FullName:        expert.FullName,
+		DateOfBirth:     expert.DateOfBirth,
+		Gender:          expert.Gender,
+		TelephoneNumber: expert.TelephoneNumber,
+		Email:           expert.Email,
+		AvatarURL:       expert.AvatarURL,
+		Verified:        expert.Verified,
+		AccountID:       expert.AccountID,
+	}
+}
@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateProfileRequest is the metadata of a new profile. The owner is the
+// account of the request and the avatar comes from the uploaded image, never
+// from the body.
+type CreateProfileRequest struct {
+	FullName   string     `json:"full_name" validate:"required,max=255"`
+	DayOfBirth *time.Time `json:"day_of_birth" validate:"required,past_date"`
+	Gender     bool       `json:"gender"`
+}
+
+func (r *CreateProfileRequest) ToProfile(userID uuid.UUID, avatarURL string) *models.Profile {
+	return &models.Profile{
+		UserID:     userID,
+		FullName:   r.FullName,
+		DayOfBirth: r.DayOfBirth,
+		Gender:     r.Gender,
+		AvatarURL:  avatarURL,
+	}
+}
+
+// UpdateProfileRequest holds the fields of a profile its owner can change.
+type UpdateProfileRequest struct {
+	FullName   string     `json:"full_name" validate:"required,max=255"`
+	DayOfBirth *time.Time `json:"day_of_birth" validate:"required,past_date"`
+	Gender     bool       `json:"gender"`
+}
+
+// ApplyTo copies the request onto the stored profile.
+func (r *UpdateProfileRequest) ApplyTo(profile *models.Profile) {
+	profile.FullName = r.FullName
+	profile.DayOfBirth = r.DayOfBirth
+	profile.Gender = r.Gender
+}
+
+// PatchProfileRequest changes only the fields it carries.
+type PatchProfileRequest struct {
+	FullName   *string    `json:"full_name" validate:"omitempty,min=1,max=255"`
+	DayOfBirth *time.Time `json:"day_of_birth" validate:"omitempty,past_date"`
+	Gender     *bool      `json:"gender"`
+}
+
+// ApplyTo copies the fields set in the request onto the stored profile.
+func (r *PatchProfileRequest) ApplyTo(profile *models.Profile) {
+	if r.FullName != nil {
+		profile.FullName = *r.FullName
+	}
+	if r.DayOfBirth != nil {
+		profile.DayOfBirth = r.DayOfBirth
+	}
+	if r.Gender != nil {
+		profile.Gender = *r.Gender
+	}
+}
+
+type ProfileResponse struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	FullName   string     `json:"full_name"`
+	DayOfBirth *time.Time `json:"day_of_birth"`
+	Gender     bool       `json:"gender"`
+	AvatarURL  string     `json:"avatar_url,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+func NewProfileResponse(profile *models.Profile) *ProfileResponse {
+	return &ProfileResponse{
+		UserID:     profile.UserID,
+		FullName:   profile.FullName,
+		DayOfBirth: profile.DayOfBirth,
+		Gender:     profile.Gender,
+		AvatarURL:  profile.AvatarURL,
+		CreatedAt:  profile.CreatedAt,
+	}
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "DH52111659-api-quan-ly-suc-khoe/internal/models"
+
+// UpdateRolePermissionsRequest replaces the permissions of a role. An empty
+// list revokes every permission of the role.
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"required,dive,required,max=50"`
+}
+
+// RoleResponse is a role with the names of the permissions it grants.
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func NewRoleResponse(role *models.Role) *RoleResponse {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
+
+func NewRoleResponses(roles []*models.Role) []*RoleResponse {
+	responses := make([]*RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		responses = append(responses, NewRoleResponse(role))
+	}
+	return responses
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessGrantHandler struct {
+	accessGrantService services.AccessGrantService
+}
+
+func NewAccessGrantHandler(accessGrantService services.AccessGrantService) *AccessGrantHandler {
+	return &AccessGrantHandler{accessGrantService: accessGrantService}
+}
+
+// GetListAccessGrant godoc
+//	@Summary		List my access grants
+//	@Description	List the experts who may read the profile and health records of the current user
+//	@Tags			Profile
+//	@Produce		json
+//	@Param			Authorization	header		string													true	"Bearer Token"
+//	@Success		200				{object}	common.ResponseNormal{data=[]dto.AccessGrantResponse}	"List of access grants"
+//	@Failure		401				{object}	common.ResponseError									"Invalid token"
+//	@Failure		500				{object}	common.ResponseError									"Internal server error"
+//	@Router			/profile/me/access-grants [get]
+func (h *AccessGrantHandler) GetListAccessGrantHandler(ctx *gin.Context) {
+	grants, err := h.accessGrantService.ListGrants(ctx, ctx.GetString("userID"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get list access grants successfully", dto.NewAccessGrantResponses(grants)))
+}
+
+// GrantAccess godoc
+//	@Summary		Grant an expert access
+//	@Description	Let an expert read the profile and health records of the current user. Granting the same expert again changes nothing.
+//	@Tags			Profile
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Bearer Token"
+//	@Param			request			body		dto.GrantAccessRequest	true	"Expert to grant"
+//	@Success		200				{object}	common.ResponseNormal	"Access granted successfully"
+//	@Failure		400				{object}	common.ResponseError	"Invalid request body or not an expert"
+//	@Failure		401				{object}	common.ResponseError	"Invalid token"
+//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Router			/profile/me/access-grants [post]
+func (h *AccessGrantHandler) GrantAccessHandler(ctx *gin.Context) {
+	var grantRequest dto.GrantAccessRequest
+	if !bindJSON(ctx, &grantRequest) {
+		return
+	}
+
+	if err := h.accessGrantService.GrantAccess(ctx, ctx.GetString("userID"), &grantRequest); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Access granted successfully", nil))
+}
+
+// RevokeAccess godoc
+//	@Summary		Revoke the access of an expert
+//	@Description	Stop an expert from reading the profile and health records of the current user
+//	@Tags			Profile
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Bearer Token"
+//	@Param			expertId		path		string					true	"Account ID of the expert"
+//	@Success		200				{object}	common.ResponseNormal	"Access revoked successfully"
+//	@Failure		401				{object}	common.ResponseError	"Invalid token"
+//	@Failure		404				{object}	common.ResponseError	"Access grant not found"
+//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Router			/profile/me/access-grants/{expertId} [delete]
+func (h *AccessGrantHandler) RevokeAccessHandler(ctx *gin.Context) {
+	if err := h.accessGrantService.RevokeAccess(ctx, ctx.GetString("userID"), ctx.Param("expertId")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Access revoked successfully", nil))
+}
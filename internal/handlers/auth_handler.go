@@ -2,8 +2,9 @@ package handlers
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
-	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"DH52111659-api-quan-ly-suc-khoe/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,21 +26,21 @@ func NewAuthHandler(accountService services.AuthService) *AuthHandler{
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			account	body		models.Account						true	"Account information"
+//	@Param			account	body		dto.RegisterAccountRequest			true	"Account information"
 //	@Success		201		{object}	common.ResponseNormal{email=string}	"Account created successfully"
 //	@Failure		400		{object}	common.ResponseError				"Invalid request body"
+//	@Failure		409		{object}	common.ResponseError				"Account already exists"
 //	@Failure		500		{object}	common.ResponseError				"Internal server error"
 //	@Router			/auth/register [post]
 func(h *AuthHandler) RegisterAccountHandler(ctx *gin.Context) {
-	var request models.Account
+	var request dto.RegisterAccountRequest
 
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
 	if err := h.accountService.RegisterAccount(ctx, &request); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
@@ -48,26 +49,26 @@ func(h *AuthHandler) RegisterAccountHandler(ctx *gin.Context) {
 
 // VerifyOTP godoc
 //	@Summary		Verify OTP for account
-//	@Description	Verify OTP for account registration or password reset
+//	@Description	Verify the email address of a new account with the OTP sent at registration
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		common.RequestOTP					true	"Request OTP information"
 //	@Success		200		{object}	common.ResponseNormal{result=bool}	"OTP verified successfully"
-//	@Failure		400		{object}	common.ResponseError				"Invalid request parameters"
+//	@Failure		400		{object}	common.ResponseError				"Invalid request parameters, wrong or expired OTP"
+//	@Failure		429		{object}	common.ResponseError				"Too many wrong attempts"
 //	@Failure		500		{object}	common.ResponseError				"Internal server error"
 //	@Router			/auth/verify-email [post]
 func(h *AuthHandler) RegisterVerifyOTPHandler(ctx *gin.Context) {
 	var request common.RequestOTP
 
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid request parameters"))
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
-	isVerified, err := h.accountService.VerifyOTP(ctx, request.Email, request.OTP, false)
+	isVerified, err := h.accountService.VerifyOTP(ctx, common.OTPPurposeVerifyEmail, request.Email, request.OTP)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
@@ -77,127 +78,158 @@ func(h *AuthHandler) RegisterVerifyOTPHandler(ctx *gin.Context) {
 
 // Login godoc
 //	@Summary		Login to the system
-//	@Description	Login to the system with email and password
+//	@Description	Login to the system with email and password. Repeated failures lock logins for the account and the client IP for a growing time. Accounts with two-factor authentication get an MFA challenge (202) instead of tokens.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			loginRequest	body		common.RequestAuth		true	"Login request information"
-//	@Success		200				{object}	common.ResponseLogin	"Login successful"
-//	@Failure		400				{object}	common.ResponseError	"Invalid request body"
-//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Param			loginRequest	body		common.RequestAuth			true	"Login request information"
+//	@Success		200				{object}	common.ResponseLogin		"Login successful"
+//	@Success		202				{object}	common.ResponseMFAChallenge	"Second factor required, complete at /auth/mfa/verify"
+//	@Failure		400				{object}	common.ResponseError		"Invalid request body"
+//	@Failure		401				{object}	common.ResponseError		"Invalid credentials"
+//	@Failure		403				{object}	common.ResponseError		"Account is locked or not verified"
+//	@Failure		429				{object}	common.ResponseError		"Too many failed logins"
+//	@Failure		500				{object}	common.ResponseError		"Internal server error"
 //	@Router			/auth/login [post]
 func(h *AuthHandler) LoginHandler(ctx *gin.Context) {
 	var loginRequest common.RequestAuth
 
-	if err := ctx.ShouldBindJSON(&loginRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindJSON(ctx, &loginRequest) {
 		return
 	}
 
-	if err := common.ValidateRequest(&loginRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	result, err := h.accountService.Login(ctx, &loginRequest, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	account, accessToken, refreshToken, err := h.accountService.Login(ctx, &loginRequest)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	if result.MFAChallenge != nil {
+		ctx.JSON(http.StatusAccepted, common.NewResponseMFAChallenge(
+			result.MFAChallenge.Token,
+			result.MFAChallenge.EnrollmentRequired,
+			int(result.MFAChallenge.ExpiresIn.Seconds()),
+		))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, common.NewResponseLogin(
-		account.ID.String(),
-		account.Role,
-		accessToken,
-		refreshToken,
+		result.Account.ID.String(),
+		result.Account.Role,
+		result.Tokens.AccessToken,
+		result.Tokens.RefreshToken,
 	))
 }
 
 // ForgotPassword godoc
 //	@Summary		Forgot password
-//	@Description	Handle forgot password request
+//	@Description	Send a password reset OTP to the email. The answer is the same whether or not the email has an account, the code is only sent when it has.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		common.RequestForgotPassword	true	"Forgot password request information"
 //	@Success		200		{object}	common.ResponseNormal			"OTP sent successfully"
 //	@Failure		400		{object}	common.ResponseError			"Invalid request body"
+//	@Failure		429		{object}	common.ResponseError			"OTP requested too often"
 //	@Failure		500		{object}	common.ResponseError			"Internal server error"
 //	@Router			/auth/password/forgot [post]
 func(h *AuthHandler) ForgotPasswordHandler(ctx *gin.Context){
 	var request common.RequestForgotPassword
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
-	if err := common.ValidateRequest(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	result, err := h.accountService.ForgotPassowrd(ctx, request.Email)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	result, err := h.accountService.ForgotPassowrd(ctx, request.Email)
+	ctx.JSON(http.StatusOK, common.NewResponseForgotPassword("OTP sent successfully", request.Email ,result))
+}
+
+// ResendOTPHandler godoc
+//	@Summary		Resend OTP
+//	@Description	Send a new OTP for the email verification or password reset flow. A new code can be requested once per cooldown and a limited number of times per day; retry_after tells the client how long to wait. The answer is the same whether or not the email has an account in that flow, the code is only sent when it has. login-step-up and email-change codes are tied to a signed-in account and cannot be resent here.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		common.RequestResendOTP								true	"Email and OTP purpose"
+//	@Success		200		{object}	common.ResponseNormal{data=common.ResponseOTPSent}	"OTP sent successfully"
+//	@Failure		400		{object}	common.ResponseError								"Invalid request body"
+//	@Failure		429		{object}	common.ResponseError								"OTP requested too often"
+//	@Failure		500		{object}	common.ResponseError								"Internal server error"
+//	@Router			/auth/otp/resend [post]
+func(h *AuthHandler) ResendOTPHandler(ctx *gin.Context) {
+	var request common.RequestResendOTP
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	status, err := h.accountService.ResendOTP(ctx, request.Purpose, request.Email)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseForgotPassword("OTP sent successfully", request.Email ,result))
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("OTP sent successfully", common.NewResponseOTPSent(
+		request.Email,
+		int(status.RetryAfter.Seconds()),
+		status.RemainingToday,
+	)))
 }
 
 // VerifyOTPHandler godoc
 //	@Summary		Verify OTP for forgot password
-//	@Description	Verify OTP for forgot password
+//	@Description	Verify the reset-password OTP and get a short-lived, single-use reset token for /auth/password/reset
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		common.RequestOTP					true	"Request OTP information"
-//	@Success		200		{object}	common.ResponseNormal{result=bool}	"OTP verified successfully"
-//	@Failure		400		{object}	common.ResponseError				"Invalid request parameters"
-//	@Failure		500		{object}	common.ResponseError				"Internal server error"
+//	@Param			request	body		common.RequestOTP										true	"Request OTP information"
+//	@Success		200		{object}	common.ResponseNormal{data=common.ResponseResetTicket}	"OTP verified successfully"
+//	@Failure		400		{object}	common.ResponseError									"Invalid request parameters, wrong or expired OTP"
+//	@Failure		429		{object}	common.ResponseError									"Too many wrong attempts"
+//	@Failure		500		{object}	common.ResponseError									"Internal server error"
 //	@Router			/auth/password/verify-otp [post]
 func(h *AuthHandler) VerifyOTPHandler(ctx *gin.Context){
 	var request common.RequestOTP
 
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid request parameters"))
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
-	isVerified, err := h.accountService.VerifyOTP(ctx, request.Email, request.OTP, true)
+	resetToken, err := h.accountService.VerifyResetPasswordOTP(ctx, request.Email, request.OTP)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseResult("OTP verified successfully", isVerified))
+	ctx.JSON(http.StatusOK, common.NewResponseNormal(
+		"OTP verified successfully",
+		common.NewResponseResetTicket(resetToken, int(services.ResetTicketTTL.Seconds())),
+	))
 }
 
 // ResetPasswordHandler godoc
 //	@Summary		Reset password
-//	@Description	Reset password after verifying OTP
+//	@Description	Set a new password with the reset token returned by /auth/password/verify-otp. Every existing session of the account is revoked.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		common.RequestAuth		true	"Reset password request information"
-//	@Success		200		{object}	common.ResponseNormal	"Password reset successfully"
-//	@Failure		400		{object}	common.ResponseError	"Invalid request body"
-//	@Failure		500		{object}	common.ResponseError	"Internal server error"
+//	@Param			request	body		common.RequestResetPassword	true	"Reset password request information"
+//	@Success		200		{object}	common.ResponseNormal		"Password reset successfully"
+//	@Failure		400		{object}	common.ResponseError		"Invalid request body or reset token"
+//	@Failure		500		{object}	common.ResponseError		"Internal server error"
 //	@Router			/auth/password/reset [post]
 func(h *AuthHandler) ResetPasswordHandler(ctx *gin.Context){
-	var request common.RequestAuth
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	var request common.RequestResetPassword
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
-	if err := common.ValidateRequest(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
-		return
-	}
-
-	if err := h.accountService.ResetPassword(ctx, &request); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	err := h.accountService.ResetPassword(ctx, &request)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -214,20 +246,48 @@ func(h *AuthHandler) ResetPasswordHandler(ctx *gin.Context){
 //	@Param			Authorization	header		string								true	"Bearer token for authentication"
 //	@Param			request			body		common.RequestChangePassword		true	"Change password request information"
 //	@Success		200				{object}	common.ResponseNormal{result=bool}	"Password changed successfully"
-//	@Failure		400				{object}	common.ResponseError				"Invalid request body"
+//	@Failure		400				{object}	common.ResponseError				"Invalid request body or wrong old password"
 //	@Failure		401				{object}	common.ResponseError				"Token must be in Bearer format"
 //	@Failure		403				{object}	common.ResponseError				"You do not have permission to access this resource"
 //	@Failure		500				{object}	common.ResponseError				"Internal server error"
 //	@Router			/auth/password/change [post]
 func(h *AuthHandler) ChangePasswordHandler(ctx *gin.Context){
 	var request common.RequestChangePassword
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	if err := h.accountService.ChangePassword(ctx, userID.(string), &request); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	if err := common.ValidateRequest(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Password changed successfully", true))
+}
+
+// ChangeLocale godoc
+//	@Summary		Change email language
+//	@Description	Choose the language (vi or en) of the emails sent to the logged-in user
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string								true	"Bearer token for authentication"
+//	@Param			request			body		common.RequestChangeLocale			true	"Locale"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Locale changed successfully"
+//	@Failure		400				{object}	common.ResponseError				"Invalid request body"
+//	@Failure		401				{object}	common.ResponseError				"Invalid token"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/auth/locale [patch]
+func(h *AuthHandler) ChangeLocaleHandler(ctx *gin.Context) {
+	var request common.RequestChangeLocale
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
@@ -237,43 +297,99 @@ func(h *AuthHandler) ChangePasswordHandler(ctx *gin.Context){
 		return
 	}
 
-	if err := h.accountService.ChangePassword(ctx, userID.(string), &request); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	if err := h.accountService.ChangeLocale(ctx, userID.(string), request.Locale); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseResult("Password changed successfully", true))
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Locale changed successfully", true))
 }
 
 
 // RefreshTokenHandler godoc
 //	@Summary		Refresh access token
-//	@Description	Refresh access token using refresh token
+//	@Description	Exchange a refresh token for a new access token and a new refresh token. The presented refresh token becomes unusable; presenting it again revokes the whole login session.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		common.RequestRefreshToken	true	"Refresh token request information"
-//	@Success		200		{object}	common.ResponseAccessToken
+//	@Success		200		{object}	common.ResponseTokens
 //	@Failure		400		{object}	common.ResponseError	"Invalid request body"
+//	@Failure		401		{object}	common.ResponseError	"Refresh token is invalid, expired or reused"
+//	@Failure		403		{object}	common.ResponseError	"Account is locked or not verified"
 //	@Failure		500		{object}	common.ResponseError	"Internal server error"
 //	@Router			/auth/token/refresh [post]
 func(h *AuthHandler) RefreshTokenHandler(ctx *gin.Context) {
 	var request common.RequestRefreshToken
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindJSON(ctx, &request) {
 		return
 	}
 
-	if err := common.ValidateRequest(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	tokens, err := h.accountService.RefreshToken(ctx, &request, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	accessToken,  err := h.accountService.RefreshToken(ctx, &request)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	ctx.JSON(http.StatusOK, common.NewResponseTokens(tokens.AccessToken, tokens.RefreshToken))
+}
+
+// LogoutHandler godoc
+//	@Summary		Logout
+//	@Description	Revoke the current access token and the refresh token issued with it
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string								true	"Bearer token for authentication"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Logged out successfully"
+//	@Failure		401				{object}	common.ResponseError				"Invalid token"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/auth/logout [post]
+func(h *AuthHandler) LogoutHandler(ctx *gin.Context) {
+	claims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("Token claims not found in context"))
+		return
+	}
+
+	if err := h.accountService.Logout(ctx, claims.(*utils.TokenClaims)); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseAccessToken(accessToken))
-}
\ No newline at end of file
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Logged out successfully", true))
+}
+
+// LogoutAllHandler godoc
+//	@Summary		Logout from all devices
+//	@Description	Revoke every access and refresh token issued to the logged-in user
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string								true	"Bearer token for authentication"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Logged out from all devices successfully"
+//	@Failure		401				{object}	common.ResponseError				"Invalid token"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/auth/logout-all [post]
+func(h *AuthHandler) LogoutAllHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	if err := h.accountService.LogoutAll(ctx, userID.(string)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Logged out from all devices successfully", true))
+}
+
+// clientInfo describes the device of the request for the session list.
+func clientInfo(ctx *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
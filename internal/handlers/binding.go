@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The bind helpers decode the request into request and validate it. On
+// failure they report the error with ctx.Error, for ErrorHandler to answer
+// with the failed fields in the language of Accept-Language, and return false.
+
+func bindJSON(ctx *gin.Context, request interface{}) bool {
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		ctx.Error(common.ErrInvalidRequestBody)
+		return false
+	}
+	return validateRequest(ctx, request)
+}
+
+func bindQuery(ctx *gin.Context, request interface{}) bool {
+	if err := ctx.ShouldBindQuery(request); err != nil {
+		ctx.Error(common.ErrInvalidRequestBody)
+		return false
+	}
+	return validateRequest(ctx, request)
+}
+
+// bindFormJSON reads the JSON held by the key field of a multipart form.
+func bindFormJSON(ctx *gin.Context, key string, request interface{}) bool {
+	if err := utils.UnmarshalFormValue(ctx, key, request); err != nil {
+		ctx.Error(common.ErrInvalidRequestBody)
+		return false
+	}
+	return validateRequest(ctx, request)
+}
+
+func validateRequest(ctx *gin.Context, request interface{}) bool {
+	if normalizer, ok := request.(common.Normalizer); ok {
+		normalizer.Normalize()
+	}
+	if err := common.ValidateRequestLocale(request, ctx.GetHeader("Accept-Language")); err != nil {
+		ctx.Error(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailOutboxHandler struct {
+	emailOutboxService services.EmailOutboxService
+}
+
+func NewEmailOutboxHandler(emailOutboxService services.EmailOutboxService) *EmailOutboxHandler {
+	return &EmailOutboxHandler{emailOutboxService: emailOutboxService}
+}
+
+// GetListEmail godoc
+//	@Summary		Get list of emails
+//	@Description	List the emails of the outbox with their delivery status, newest first. With mode=cursor the list is paged by next_cursor instead of page and counted only with with_total=true.
+//	@Tags			Email
+//	@Produce		json
+//	@Param			Authorization	header		string												true	"Bearer Token"
+//	@Param			status			query		string												false	"Delivery status"				Enums(pending, sent, dead, expired)
+//	@Param			mode			query		string												false	"Paging mode (default is page)"	Enums(page, cursor)
+//	@Param			page			query		int													false	"Page number (default is 1)"
+//	@Param			cursor			query		string												false	"next_cursor of the previous page, implies mode=cursor"
+//	@Param			with_total		query		bool												false	"Count the total in cursor mode"
+//	@Param			limit			query		int													false	"Number of emails per page (default is 10, at most 100)"
+//	@Param			order			query		string												false	"Sort direction by created_at (default is desc)"	Enums(asc, desc)
+//	@Success		200				{object}	common.ResponseNormal{data=[]models.EmailOutbox}	"List of emails"
+//	@Failure		400				{object}	common.ResponseError								"Invalid query parameters"
+//	@Failure		500				{object}	common.ResponseError								"Internal server error"
+//	@Router			/admin/emails [get]
+func (h *EmailOutboxHandler) GetListEmailHandler(ctx *gin.Context) {
+	var paging common.Paging
+	if !bindQuery(ctx, &paging) {
+		return
+	}
+
+	status := ctx.Query("status")
+	switch status {
+	case "", models.EmailStatusPending, models.EmailStatusSent, models.EmailStatusDead, models.EmailStatusExpired:
+	default:
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid status"))
+		return
+	}
+
+	emails, err := h.emailOutboxService.ListEmails(ctx, &paging, status)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponsePaging("Get list emails successfully", emails, paging))
+}
+
+// GetEmailById godoc
+//	@Summary		Get email by ID
+//	@Description	Get the delivery status of an email of the outbox
+//	@Tags			Email
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Param			id				path		int												true	"Email ID"
+//	@Success		200				{object}	common.ResponseNormal{data=models.EmailOutbox}	"Email details"
+//	@Failure		400				{object}	common.ResponseError							"Invalid email ID"
+//	@Failure		404				{object}	common.ResponseError							"Email not found"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/admin/emails/{id} [get]
+func (h *EmailOutboxHandler) GetEmailByIdHandler(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid email ID"))
+		return
+	}
+
+	email, err := h.emailOutboxService.GetEmail(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get email successfully", email))
+}
+
+// RetryEmail godoc
+//	@Summary		Retry a dead email
+//	@Description	Queue a dead-lettered email again with a fresh attempt budget
+//	@Tags			Email
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Bearer Token"
+//	@Param			id				path		int									true	"Email ID"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Email queued again"
+//	@Failure		400				{object}	common.ResponseError				"Invalid email ID"
+//	@Failure		404				{object}	common.ResponseError				"Email not found"
+//	@Failure		409				{object}	common.ResponseError				"Email is not dead"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/admin/emails/{id}/retry [post]
+func (h *EmailOutboxHandler) RetryEmailHandler(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid email ID"))
+		return
+	}
+
+	err = h.emailOutboxService.RetryEmail(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Email queued again", true))
+}
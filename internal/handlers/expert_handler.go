@@ -3,7 +3,7 @@ package handlers
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
 	"DH52111659-api-quan-ly-suc-khoe/config"
-	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/services"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
 	"net/http"
@@ -29,14 +29,15 @@ func NewExpertHandler(service services.ExpertService) *ExpertHandler{
 // @Param        Authorization  header    string  true  "Bearer token"
 // @Param        image         formData  file    false  "Expert image file (max 10MB)"
 // @Param        metadata      formData  string  true   "Expert data in JSON format" 
-// @Success      201  {object}  common.ResponseNormal{data=object}
+// @Success      201  {object}  common.ResponseNormal{data=dto.ExpertResponse}
 // @Failure      400  {object}  common.ResponseError
 // @Failure      401  {object}  common.ResponseError
 // @Failure      403  {object}  common.ResponseError
+// @Failure      409  {object}  common.ResponseError
 // @Failure      500  {object}  common.ResponseError
 // @Router       /admin/expert [post]
 func(h *ExpertHandler) CreateExpertHandler(ctx *gin.Context) {
-	var createExpertRequest models.ExpertCreate
+	var createExpertRequest dto.CreateExpertRequest
 
 	avatarURL, err, isUploadFile := utils.HandleFileUpload(ctx, "image", config.AppConfig.UploadDir);
 	if err != nil && isUploadFile{
@@ -44,27 +45,18 @@ func(h *ExpertHandler) CreateExpertHandler(ctx *gin.Context) {
 		return 
 	}
 
-	if err := utils.UnmarshalFormValue(ctx, "metadata", &createExpertRequest); err != nil {
+	if !bindFormJSON(ctx, "metadata", &createExpertRequest) {
 		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
 		return 
 	}
 
-	if err := common.ValidateRequest(createExpertRequest); err != nil{
-		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
-		return 
-	}
-
-	createExpertRequest.AvatarURL = avatarURL
-
-	expert, err := h.expertService.CreateExpert(ctx, &createExpertRequest)
+	expert, err := h.expertService.CreateExpert(ctx, &createExpertRequest, avatarURL)
 	if err != nil {
 		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseNormal("Expert created successfully", expert))
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Expert created successfully", dto.NewExpertResponse(expert)))
 }
 
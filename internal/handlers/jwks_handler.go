@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	tokenService *utils.TokenService
+}
+
+func NewJWKSHandler(tokenService *utils.TokenService) *JWKSHandler {
+	return &JWKSHandler{tokenService: tokenService}
+}
+
+// GetJWKSHandler serves the public keys of the token service as a JWK set so
+// other services can verify our tokens without the signing key. It lives at
+// /.well-known/jwks.json, outside of the API base path, so it is not part of
+// the swagger document.
+func (h *JWKSHandler) GetJWKSHandler(ctx *gin.Context) {
+	// Verifiers may cache the set, a new key is published ahead of its use
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.tokenService.JWKS())
+}
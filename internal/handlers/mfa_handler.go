@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	authService services.AuthService
+	mfaService  services.MFAService
+}
+
+func NewMFAHandler(authService services.AuthService, mfaService services.MFAService) *MFAHandler {
+	return &MFAHandler{
+		authService: authService,
+		mfaService:  mfaService,
+	}
+}
+
+// VerifyMFA godoc
+//	@Summary		Complete login with a second factor
+//	@Description	Answer the MFA challenge returned by /auth/login with a TOTP code or a recovery code. For an account enrolling because of policy, the code confirms the enrollment and the recovery codes are returned once.
+//	@Tags			MFA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		common.RequestMFAVerify	true	"MFA token and code"
+//	@Success		200		{object}	common.ResponseLogin	"Login successful"
+//	@Failure		400		{object}	common.ResponseError	"Invalid request body"
+//	@Failure		401		{object}	common.ResponseError	"Invalid code or challenge"
+//	@Failure		403		{object}	common.ResponseError	"Account is locked or not verified"
+//	@Failure		429		{object}	common.ResponseError	"Too many wrong codes"
+//	@Failure		500		{object}	common.ResponseError	"Internal server error"
+//	@Router			/auth/mfa/verify [post]
+func (h *MFAHandler) VerifyMFAHandler(ctx *gin.Context) {
+	var request common.RequestMFAVerify
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	result, err := h.authService.CompleteMFALogin(ctx, &request, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := common.NewResponseLogin(
+		result.Account.ID.String(),
+		result.Account.Role,
+		result.Tokens.AccessToken,
+		result.Tokens.RefreshToken,
+	)
+	response.RecoveryCodes = result.RecoveryCodes
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// EnrollMFA godoc
+//	@Summary		Start a required TOTP enrollment during login
+//	@Description	For a login challenge with enrollment_required, generate the TOTP secret and otpauth URI. Finish with /auth/mfa/verify.
+//	@Tags			MFA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		common.RequestMFAToken									true	"MFA token"
+//	@Success		200		{object}	common.ResponseNormal{data=common.ResponseTOTPSetup}	"TOTP secret generated"
+//	@Failure		400		{object}	common.ResponseError									"Invalid request body"
+//	@Failure		409		{object}	common.ResponseError									"2FA already enabled"
+//	@Failure		401		{object}	common.ResponseError									"Invalid challenge"
+//	@Failure		500		{object}	common.ResponseError									"Internal server error"
+//	@Router			/auth/mfa/enroll [post]
+func (h *MFAHandler) EnrollMFAHandler(ctx *gin.Context) {
+	var request common.RequestMFAToken
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	setup, err := h.mfaService.SetupTOTPForChallenge(ctx, request.MFAToken)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("TOTP secret generated", common.NewResponseTOTPSetup(setup.Secret, setup.URI)))
+}
+
+// SetupTOTP godoc
+//	@Summary		Start TOTP enrollment
+//	@Description	Generate a TOTP secret and the otpauth URI to render as a QR code. Two-factor authentication is enabled by /auth/mfa/totp/confirm.
+//	@Tags			MFA
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string													true	"Bearer token for authentication"
+//	@Success		200				{object}	common.ResponseNormal{data=common.ResponseTOTPSetup}	"TOTP secret generated"
+//	@Failure		409				{object}	common.ResponseError									"2FA already enabled"
+//	@Failure		401				{object}	common.ResponseError									"Invalid token"
+//	@Failure		500				{object}	common.ResponseError									"Internal server error"
+//	@Router			/auth/mfa/totp/setup [post]
+func (h *MFAHandler) SetupTOTPHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	setup, err := h.mfaService.SetupTOTP(ctx, userID.(string))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("TOTP secret generated", common.NewResponseTOTPSetup(setup.Secret, setup.URI)))
+}
+
+// ConfirmTOTP godoc
+//	@Summary		Confirm TOTP enrollment
+//	@Description	Enable two-factor authentication with a code from the authenticator app. The recovery codes are returned only this once.
+//	@Tags			MFA
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string														true	"Bearer token for authentication"
+//	@Param			request			body		common.RequestTOTPCode										true	"TOTP code"
+//	@Success		200				{object}	common.ResponseNormal{data=common.ResponseRecoveryCodes}	"Two-factor authentication enabled"
+//	@Failure		400				{object}	common.ResponseError										"Invalid request body or setup missing"
+//	@Failure		409				{object}	common.ResponseError										"2FA already enabled"
+//	@Failure		401				{object}	common.ResponseError										"Invalid code"
+//	@Failure		429				{object}	common.ResponseError										"Too many wrong codes"
+//	@Failure		500				{object}	common.ResponseError										"Internal server error"
+//	@Router			/auth/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTPHandler(ctx *gin.Context) {
+	var request common.RequestTOTPCode
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.ConfirmTOTP(ctx, userID.(string), request.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Two-factor authentication enabled", common.NewResponseRecoveryCodes(recoveryCodes)))
+}
+
+// DisableTOTP godoc
+//	@Summary		Disable TOTP
+//	@Description	Disable two-factor authentication with the password and a current code. Not allowed for roles that policy forces into 2FA.
+//	@Tags			MFA
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string								true	"Bearer token for authentication"
+//	@Param			request			body		common.RequestDisableTOTP			true	"Password and TOTP code"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Two-factor authentication disabled"
+//	@Failure		400				{object}	common.ResponseError				"Invalid request body"
+//	@Failure		409				{object}	common.ResponseError				"2FA not enabled"
+//	@Failure		401				{object}	common.ResponseError				"Invalid password or code"
+//	@Failure		403				{object}	common.ResponseError				"2FA is required by policy"
+//	@Failure		429				{object}	common.ResponseError				"Too many wrong passwords or codes"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/auth/mfa/totp/disable [post]
+func (h *MFAHandler) DisableTOTPHandler(ctx *gin.Context) {
+	var request common.RequestDisableTOTP
+	if !bindJSON(ctx, &request) {
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	err := h.mfaService.DisableTOTP(ctx, userID.(string), &request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Two-factor authentication disabled", true))
+}
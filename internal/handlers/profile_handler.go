@@ -3,10 +3,11 @@ package handlers
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
 	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/services"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
-	"log"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -22,20 +23,20 @@ func NewProfileHandler(profileService services.ProfileService) *ProfileHandler{
 
 //CreateProfile godoc
 //@Summary Create a new profile
-//@Description Create user profile with file image and json profile
+//@Description Create the profile of the current user with file image and json profile
 //@Tags Profile
 //@Accept multipart/form-data
 //@Produce json
 //@Param Authorization header string true "Bearer Token"
 //@Param image formData file false "Profile image file (max 10MB)"
 //@Param metadata formData string true "Json body for profile"
-//@Success 201 {object} common.ResponseNormal{data=object} "Profile created successfully"
+//@Success 201 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile created successfully"
 //@Failure 400 {object} common.ResponseError "invalid request form-data"
 //@Failure 401 {object} common.ResponseError "invalid token"
 //@Failure 500 {object} common.ResponseError "Internal server error"
 //@Router /profile [post] 
 func(h *ProfileHandler) CreateProfileHandler(ctx *gin.Context) { 
-	var profileRequest models.Profile
+	var profileRequest dto.CreateProfileRequest
 
 	avatarURL, err, isUploadFile := utils.HandleFileUpload(ctx, "image", config.AppConfig.UploadDir)
 	if err != nil && isUploadFile{
@@ -43,33 +44,102 @@ func(h *ProfileHandler) CreateProfileHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := utils.UnmarshalFormValue(ctx, "metadata", &profileRequest); err != nil {
+	if !bindFormJSON(ctx, "metadata", &profileRequest) {
 		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
 		return
 	}
 
-	if err := common.ValidateRequest(profileRequest); err != nil {
+	profile, err := h.profileService.CreateProfile(ctx, ctx.GetString("userID"), &profileRequest, avatarURL)
+	if err != nil {
 		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
+	
+	ctx.JSON(http.StatusCreated, common.NewResponseNormal("Profile created successfully", dto.NewProfileResponse(profile)))
+} 
+
+//GetMyProfile godoc
+//@Summary Get the profile of the current user
+//@Description Get the profile of the account of the token
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile details"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/me [get]
+func(h *ProfileHandler) GetMyProfileHandler(ctx *gin.Context) {
+	h.getProfile(ctx, ctx.GetString("userID"))
+}
+
+//GetProfile godoc
+//@Summary Get user profile
+//@Description Get the profile of a user. Allowed to the owner, to roles with profile:read:any and to experts the owner granted access to.
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param id path string true "User ID"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile details"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "No access to the profile"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/{id} [get]
+func(h *ProfileHandler) GetProfileHandler(ctx *gin.Context) {
+	h.getProfile(ctx, ctx.Param("id"))
+}
 
-	profileRequest.AvatarURL = avatarURL
+//GetProfileById godoc
+//@Summary Get user profile as admin
+//@Description Get the profile of any user. Requires profile:read:any.
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param id path string true "User ID"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile details"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "Permission denied"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /admin/profiles/{id} [get]
+func(h *ProfileHandler) GetProfileByIdHandler(ctx *gin.Context) {
+	h.getProfile(ctx, ctx.Param("id"))
+}
 
-	profile, err := h.profileService.CreateProfile(ctx, &profileRequest)
+func(h *ProfileHandler) getProfile(ctx *gin.Context, userID string) {
+	profile, err := h.profileService.GetProfileByID(ctx, userID)
 	if err != nil {
-		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
-	
-	ctx.JSON(http.StatusCreated, common.NewResponseNormal("Profile created successfully", profile))
-} 
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get profile successfully", dto.NewProfileResponse(profile)))
+}
+
+//UpdateMyProfile godoc
+//@Summary Update the profile of the current user
+//@Description Update the profile of the account of the token with file image and json profile
+//@Tags Profile
+//@Accept multipart/form-data
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param image formData file false "Profile image file (max 10MB)"
+//@Param metadata formData string true "Json body for profile"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile updated successfully"
+//@Failure 400 {object} common.ResponseError "invalid request form-data"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/me [put]
+func(h *ProfileHandler) UpdateMyProfileHandler(ctx *gin.Context) {
+	h.putProfile(ctx, ctx.GetString("userID"))
+}
 
 // UpdateProfile godoc
 //@Summary Update user profile
-//@Description Update user profile with file image and json profile
+//@Description Update user profile with file image and json profile. Allowed to the owner and to roles with profile:update:any.
 //@Tags Profile
 //@Accept multipart/form-data
 //@Produce json
@@ -77,47 +147,213 @@ func(h *ProfileHandler) CreateProfileHandler(ctx *gin.Context) {
 //@Param id path string true "User ID"
 //@Param image formData file false "Profile image file (max 10MB)"
 //@Param metadata formData string true "Json body for profile"
-//@Success 200 {object} common.ResponseNormal{data=object} "Profile updated successfully"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile updated successfully"
 //@Failure 400 {object} common.ResponseError "invalid request form-data"
 //@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "No access to the profile"
 //@Failure 404 {object} common.ResponseError "Profile not found"
 //@Failure 500 {object} common.ResponseError "Internal server error"
 // @Router /profile/{id} [put]
 func(h *ProfileHandler) UpdateProfileHandler(ctx *gin.Context) {
-	var updateProfileRequest models.Profile
+	h.putProfile(ctx, ctx.Param("id"))
+}
+
+func(h *ProfileHandler) putProfile(ctx *gin.Context, cond string) {
+	var updateProfileRequest dto.UpdateProfileRequest
+
+	h.updateProfile(ctx, cond, &updateProfileRequest, func(avatarURL string) (*models.Profile, error) {
+		return h.profileService.UpdateProfile(ctx, cond, &updateProfileRequest, avatarURL)
+	})
+}
+
+//PatchMyProfile godoc
+//@Summary Partially update the profile of the current user
+//@Description Change only the fields set in the json profile. The avatar is replaced only when an image is sent.
+//@Tags Profile
+//@Accept multipart/form-data
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param image formData file false "Profile image file (max 10MB)"
+//@Param metadata formData string false "Json body with the fields to change"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile updated successfully"
+//@Failure 400 {object} common.ResponseError "invalid request form-data"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/me [patch]
+func(h *ProfileHandler) PatchMyProfileHandler(ctx *gin.Context) {
+	h.patchProfile(ctx, ctx.GetString("userID"))
+}
+
+//PatchProfile godoc
+//@Summary Partially update user profile
+//@Description Change only the fields set in the json profile. The avatar is replaced only when an image is sent. Allowed to the owner and to roles with profile:update:any.
+//@Tags Profile
+//@Accept multipart/form-data
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param id path string true "User ID"
+//@Param image formData file false "Profile image file (max 10MB)"
+//@Param metadata formData string false "Json body with the fields to change"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Profile updated successfully"
+//@Failure 400 {object} common.ResponseError "invalid request form-data"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "No access to the profile"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/{id} [patch]
+func(h *ProfileHandler) PatchProfileHandler(ctx *gin.Context) {
+	h.patchProfile(ctx, ctx.Param("id"))
+}
 
+func(h *ProfileHandler) patchProfile(ctx *gin.Context, cond string) {
+	var patchProfileRequest dto.PatchProfileRequest
+
+	h.updateProfile(ctx, cond, &patchProfileRequest, func(avatarURL string) (*models.Profile, error) {
+		return h.profileService.PatchProfile(ctx, cond, &patchProfileRequest, avatarURL)
+	})
+}
+
+// updateProfile reads the image and the metadata of the form into request,
+// runs update with the name of the uploaded image and removes the avatar it
+// replaced.
+func(h *ProfileHandler) updateProfile(
+	ctx *gin.Context,
+	cond string,
+	request interface{},
+	update func(avatarURL string) (*models.Profile, error),
+	) {
 	avatarURL, err, isUploadFile := utils.HandleFileUpload(ctx, "image", config.AppConfig.UploadDir)
 	if err != nil && isUploadFile{
 		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
 		return 
 	}
 
-	if err := utils.UnmarshalFormValue(ctx, "metadata", &updateProfileRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	if !bindFormJSON(ctx, "metadata", request) {
+		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
 		return
 	}
 
-	if err := common.ValidateRequest(updateProfileRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	// The previous avatar is removed once a new image replaces it
+	var deleteURL string
+	if avatarURL != "" {
+		currentProfile, err := h.profileService.GetProfileByID(ctx, cond)
+		if err != nil {
+			utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
+			ctx.Error(err)
+			return
+		}
+		deleteURL = currentProfile.AvatarURL
+	}
+
+	profile, err := update(avatarURL)
+	if err != nil {
+		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
+		ctx.Error(err)
 		return
 	}
 
-	cond := ctx.Param("id")
-	deleteURL := updateProfileRequest.AvatarURL
-	
-	log.Print(deleteURL)
-	updateProfileRequest.AvatarURL = avatarURL
-	 // Store the URL to delete later if needed
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Profile updated successfully", dto.NewProfileResponse(profile)))
 
-	profile, err := h.profileService.UpdateProfile(ctx, cond, &updateProfileRequest); 
+	// Clean up the replaced file, unless the update kept it. The response is
+	// sent, so ErrorHandler only logs a failure.
+	if deleteURL != avatarURL {
+		if err := utils.HandleFileDeleted(deleteURL, config.AppConfig.UploadDir); err != nil {
+			ctx.Error(fmt.Errorf("error deleting replaced avatar: %w", err))
+		}
+	}
+}
+
+//RemoveMyAvatar godoc
+//@Summary Remove the avatar of the current user
+//@Description Clear the avatar of the profile and delete the stored image
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Avatar removed successfully"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/me/avatar [delete]
+func(h *ProfileHandler) RemoveMyAvatarHandler(ctx *gin.Context) {
+	h.removeAvatar(ctx, ctx.GetString("userID"))
+}
+
+//RemoveAvatar godoc
+//@Summary Remove the avatar of a user
+//@Description Clear the avatar of the profile and delete the stored image. Allowed to the owner and to roles with profile:update:any.
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param id path string true "User ID"
+//@Success 200 {object} common.ResponseNormal{data=dto.ProfileResponse} "Avatar removed successfully"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "No access to the profile"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/{id}/avatar [delete]
+func(h *ProfileHandler) RemoveAvatarHandler(ctx *gin.Context) {
+	h.removeAvatar(ctx, ctx.Param("id"))
+}
+
+func(h *ProfileHandler) removeAvatar(ctx *gin.Context, cond string) {
+	profile, removedURL, err := h.profileService.RemoveAvatar(ctx, cond)
 	if err != nil {
-		utils.HandleFileDeleted(avatarURL, config.AppConfig.UploadDir)
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseNormal("Profile updated successfully", profile))
+	if err := utils.HandleFileDeleted(removedURL, config.AppConfig.UploadDir); err != nil {
+		ctx.Error(fmt.Errorf("error deleting avatar: %w", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Avatar removed successfully", dto.NewProfileResponse(profile)))
+}
+
+//DeleteMyProfile godoc
+//@Summary Delete the profile of the current user
+//@Description Delete the profile and its stored avatar. The account stays.
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Success 200 {object} common.ResponseNormal "Profile deleted successfully"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/me [delete]
+func(h *ProfileHandler) DeleteMyProfileHandler(ctx *gin.Context) {
+	h.deleteProfile(ctx, ctx.GetString("userID"))
+}
+
+//DeleteProfile godoc
+//@Summary Delete user profile
+//@Description Delete the profile and its stored avatar. The account stays. Allowed to the owner and to roles with profile:update:any.
+//@Tags Profile
+//@Produce json
+//@Param Authorization header string true "Bearer Token"
+//@Param id path string true "User ID"
+//@Success 200 {object} common.ResponseNormal "Profile deleted successfully"
+//@Failure 401 {object} common.ResponseError "invalid token"
+//@Failure 403 {object} common.ResponseError "No access to the profile"
+//@Failure 404 {object} common.ResponseError "Profile not found"
+//@Failure 500 {object} common.ResponseError "Internal server error"
+//@Router /profile/{id} [delete]
+func(h *ProfileHandler) DeleteProfileHandler(ctx *gin.Context) {
+	h.deleteProfile(ctx, ctx.Param("id"))
+}
+
+func(h *ProfileHandler) deleteProfile(ctx *gin.Context, cond string) {
+	profile, err := h.profileService.DeleteProfile(ctx, cond)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := utils.HandleFileDeleted(profile.AvatarURL, config.AppConfig.UploadDir); err != nil {
+		ctx.Error(fmt.Errorf("error deleting avatar: %w", err))
+		return
+	}
 
-	// Clean up the uploaded file after use
-	utils.HandleFileDeleted(deleteURL, config.AppConfig.UploadDir)
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Profile deleted successfully", nil))
 }
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	authorizationService services.AuthorizationService
+}
+
+func NewRoleHandler(authorizationService services.AuthorizationService) *RoleHandler {
+	return &RoleHandler{authorizationService: authorizationService}
+}
+
+// GetListRole godoc
+//	@Summary		Get list of roles
+//	@Description	List the roles with the permissions each of them grants
+//	@Tags			Role
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Success		200				{object}	common.ResponseNormal{data=[]dto.RoleResponse}	"List of roles"
+//	@Failure		403				{object}	common.ResponseError							"Permission denied"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/admin/roles [get]
+func (h *RoleHandler) GetListRoleHandler(ctx *gin.Context) {
+	roles, err := h.authorizationService.ListRoles(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get list roles successfully", dto.NewRoleResponses(roles)))
+}
+
+// GetListPermission godoc
+//	@Summary		Get list of permissions
+//	@Description	List every permission that can be granted to a role
+//	@Tags			Role
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Success		200				{object}	common.ResponseNormal{data=[]models.Permission}	"List of permissions"
+//	@Failure		403				{object}	common.ResponseError							"Permission denied"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/admin/permissions [get]
+func (h *RoleHandler) GetListPermissionHandler(ctx *gin.Context) {
+	permissions, err := h.authorizationService.ListPermissions(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get list permissions successfully", permissions))
+}
+
+// UpdateRolePermissions godoc
+//	@Summary		Update the permissions of a role
+//	@Description	Replace the permissions granted by a role. An empty list revokes them all. The admin role must keep role:manage.
+//	@Tags			Role
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Param			role			path		string											true	"Role name"
+//	@Param			request			body		dto.UpdateRolePermissionsRequest				true	"Permissions of the role"
+//	@Success		200				{object}	common.ResponseNormal{data=dto.RoleResponse}	"Role permissions updated successfully"
+//	@Failure		400				{object}	common.ResponseError							"Invalid request body or unknown permission"
+//	@Failure		403				{object}	common.ResponseError							"Permission denied"
+//	@Failure		404				{object}	common.ResponseError							"Role not found"
+//	@Failure		409				{object}	common.ResponseError							"role:manage cannot be revoked from admin"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/admin/roles/{role}/permissions [put]
+func (h *RoleHandler) UpdateRolePermissionsHandler(ctx *gin.Context) {
+	var updateRequest dto.UpdateRolePermissionsRequest
+	if !bindJSON(ctx, &updateRequest) {
+		return
+	}
+
+	role, err := h.authorizationService.UpdateRolePermissions(ctx, ctx.Param("role"), &updateRequest)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Update role permissions successfully", dto.NewRoleResponse(role)))
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// ListMySessions godoc
+//	@Summary		List my sessions
+//	@Description	List the devices the logged-in user is signed in on. The session of the current token is flagged with current=true.
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string											true	"Bearer token for authentication"
+//	@Success		200				{object}	common.ResponseNormal{data=[]models.Session}	"Get sessions successfully"
+//	@Failure		401				{object}	common.ResponseError							"Invalid token"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/auth/sessions [get]
+func (h *SessionHandler) ListMySessionsHandler(ctx *gin.Context) {
+	claims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("Token claims not found in context"))
+		return
+	}
+
+	tokenClaims := claims.(*utils.TokenClaims)
+	sessions, err := h.sessionService.ListSessions(ctx, tokenClaims.UserID, tokenClaims.FamilyID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get sessions successfully", sessions))
+}
+
+// RevokeMySession godoc
+//	@Summary		Revoke one of my sessions
+//	@Description	Sign the logged-in user out of a device. The refresh token of the session can no longer be used.
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			Authorization	header		string								true	"Bearer token for authentication"
+//	@Param			id				path		string								true	"Session ID"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Session revoked successfully"
+//	@Failure		400				{object}	common.ResponseError				"Invalid session ID"
+//	@Failure		401				{object}	common.ResponseError				"Invalid token"
+//	@Failure		404				{object}	common.ResponseError				"Session not found"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySessionHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.NewResponseError("User ID not found in context"))
+		return
+	}
+
+	sessionID := ctx.Param("id")
+	h.revokeSession(ctx, sessionID, func() error {
+		return h.sessionService.RevokeSession(ctx, userID.(string), sessionID)
+	})
+}
+
+// ListUserSessions godoc
+//	@Summary		List the sessions of a user
+//	@Description	List the devices a user account is signed in on
+//	@Tags			User
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Param			id				path		string											true	"User ID"
+//	@Success		200				{object}	common.ResponseNormal{data=[]models.Session}	"Get sessions successfully"
+//	@Failure		400				{object}	common.ResponseError							"Invalid user ID"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Router			/admin/user/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessionsHandler(ctx *gin.Context) {
+	userId := ctx.Param("id")
+	if _, err := uuid.Parse(userId); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid user ID"))
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(ctx, userId, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get sessions successfully", sessions))
+}
+
+// RevokeUserSession godoc
+//	@Summary		Revoke a session of a user
+//	@Description	Sign a user account out of a device. The refresh token of the session can no longer be used.
+//	@Tags			User
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Bearer Token"
+//	@Param			id				path		string								true	"User ID"
+//	@Param			sessionId		path		string								true	"Session ID"
+//	@Success		200				{object}	common.ResponseNormal{result=bool}	"Session revoked successfully"
+//	@Failure		400				{object}	common.ResponseError				"Invalid user or session ID"
+//	@Failure		403				{object}	common.ResponseError				"Permission denied or the role of the user outranks yours"
+//	@Failure		404				{object}	common.ResponseError				"Session not found"
+//	@Failure		500				{object}	common.ResponseError				"Internal server error"
+//	@Router			/admin/user/{id}/sessions/{sessionId} [delete]
+func (h *SessionHandler) RevokeUserSessionHandler(ctx *gin.Context) {
+	userId := ctx.Param("id")
+	if _, err := uuid.Parse(userId); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid user ID"))
+		return
+	}
+
+	sessionID := ctx.Param("sessionId")
+	h.revokeSession(ctx, sessionID, func() error {
+		return h.sessionService.RevokeUserSession(ctx, actorFromContext(ctx), userId, sessionID)
+	})
+}
+
+// revokeSession checks the session ID before revoke runs.
+func (h *SessionHandler) revokeSession(ctx *gin.Context, sessionID string, revoke func() error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("Invalid session ID"))
+		return
+	}
+
+	err := revoke()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseResult("Session revoked successfully", true))
+}
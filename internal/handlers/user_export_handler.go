@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportContentTypes = map[string]string{
+	common.ExportFormatCSV:  "text/csv; charset=utf-8",
+	common.ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type UserExportHandler struct {
+	userExportService services.UserExportService
+}
+
+func NewUserExportHandler(userExportService services.UserExportService) *UserExportHandler {
+	return &UserExportHandler{userExportService: userExportService}
+}
+
+// ExportUsers godoc
+//	@Summary		Export users
+//	@Description	Download the accounts with their profile as a CSV or XLSX file, newest first. The filters are the ones of the user list. CSV files are streamed; an XLSX file is limited to 50000 accounts. The file never contains passwords.
+//	@Tags			User
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			Authorization	header		string					true	"Bearer Token"
+//	@Param			format			query		string					false	"File format (default is csv)"	Enums(csv, xlsx)
+//	@Param			status			query		string					false	"Account status"				Enums(active, locked)
+//	@Param			role			query		string					false	"Account role"					Enums(admin, user, expert, support)
+//	@Param			is_verified		query		bool					false	"Email verification state"
+//	@Param			created_from	query		string					false	"Created at or after (RFC 3339)"
+//	@Param			created_to		query		string					false	"Created at or before (RFC 3339)"
+//	@Param			search			query		string					false	"Part of the email"
+//	@Success		200				{file}		file					"Export file"
+//	@Failure		400				{object}	common.ResponseError	"Invalid query parameters or too many accounts for XLSX"
+//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Router			/admin/users/export [get]
+func (h *UserExportHandler) ExportUsersHandler(ctx *gin.Context) {
+	var exportRequest common.RequestExportUsers
+	if !bindQuery(ctx, &exportRequest) {
+		return
+	}
+
+	var filter common.AccountFilter
+	if !bindQuery(ctx, &filter) {
+		return
+	}
+
+	if exportRequest.Format == "" {
+		exportRequest.Format = common.ExportFormatCSV
+	}
+
+	writer := &downloadWriter{
+		ctx:         ctx,
+		contentType: exportContentTypes[exportRequest.Format],
+		filename:    fmt.Sprintf("users-%s.%s", time.Now().Format("20060102-150405"), exportRequest.Format),
+	}
+	if err := h.userExportService.ExportUsers(ctx, &filter, exportRequest.Format, writer); err != nil {
+		ctx.Error(err)
+		return
+	}
+}
+
+// downloadWriter sends the download headers with the first byte of the file,
+// so an error found before still gets a JSON answer from ErrorHandler.
+type downloadWriter struct {
+	ctx         *gin.Context
+	contentType string
+	filename    string
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	if !w.ctx.Writer.Written() {
+		w.ctx.Header("Content-Type", w.contentType)
+		w.ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+	}
+	return w.ctx.Writer.Write(p)
+}
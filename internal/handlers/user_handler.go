@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
-	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
 )
 
@@ -18,36 +20,32 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 
 // CreateUser godoc
 //	@Summary		Create a new user account
-//	@Description	Create a new user account with email and password
+//	@Description	Create a verified account with email, password and an optional role (default user). The role cannot rank above your own.
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string										true	"Bearer Token"
-//	@Param			account			body		models.AccountCreate						true	"Account information"
-//	@Success		201				{object}	common.ResponseNormal{data=models.Account}	"Account created successfully"
-//	@Failure		400				{object}	common.ResponseError						"Invalid request body"
-//	@Failure		500				{object}	common.ResponseError						"Internal server error"
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Param			account			body		dto.CreateAccountRequest						true	"Account information"
+//	@Success		201				{object}	common.ResponseNormal{data=dto.AccountResponse}	"Account created successfully"
+//	@Failure		400				{object}	common.ResponseError							"Invalid request body or unknown role"
+//	@Failure		403				{object}	common.ResponseError							"Permission denied or the role outranks yours"
+//	@Failure		409				{object}	common.ResponseError							"Account already exists"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
 //	@Router			/admin/user [post]
 func (h *UserHandler) CreateUserHandler(ctx *gin.Context) {
-	var accountRequest models.AccountCreate
-
-	if err := ctx.ShouldBindJSON(&accountRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
-		return
-	}
+	var accountRequest dto.CreateAccountRequest
 
-	if err := common.ValidateRequest(accountRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	if !bindJSON(ctx, &accountRequest) {
 		return
 	}
 
-	account, err := h.userService.CreateAccount(ctx, &accountRequest)
+	account, err := h.userService.CreateAccount(ctx, actorFromContext(ctx), &accountRequest)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, common.NewResponseNormal("Create account successfully", account))
+	ctx.JSON(http.StatusCreated, common.NewResponseNormal("Create account successfully", dto.NewAccountResponse(account)))
 }
 
 // ResetPasswordUser godoc
@@ -60,23 +58,19 @@ func (h *UserHandler) CreateUserHandler(ctx *gin.Context) {
 //	@Param			resetPasswordRequest	body		common.RequestAuth		true	"Reset password request"
 //	@Success		200						{object}	common.ResponseNormal	"Password reset successfully"
 //	@Failure		400						{object}	common.ResponseError	"Invalid request body"
+//	@Failure		403						{object}	common.ResponseError	"Permission denied or the role of the user outranks yours"
+//	@Failure		404						{object}	common.ResponseError	"User not found"
 //	@Failure		500						{object}	common.ResponseError	"Internal server error"
 //	@Router			/admin/user/reset-password [post]
 func (h *UserHandler) ResetPasswordUserHandler(ctx *gin.Context) {
 	var resetPasswordRequest common.RequestAuth
 
-	if err := ctx.ShouldBindJSON(&resetPasswordRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
-		return
-	}
-
-	if err := common.ValidateRequest(resetPasswordRequest); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(err.Error()))
+	if !bindJSON(ctx, &resetPasswordRequest) {
 		return
 	}
 
-	if err := h.userService.ResetPassword(ctx, &resetPasswordRequest); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	if err := h.userService.ResetPassword(ctx, actorFromContext(ctx), &resetPasswordRequest); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -85,40 +79,47 @@ func (h *UserHandler) ResetPasswordUserHandler(ctx *gin.Context) {
 
 // GetListUser godoc
 //	@Summary		Get list of users
-//	@Description	Get a list of users with pagination
+//	@Description	Get a list of accounts with pagination, filters on status, role, verification and creation time, email search and sorting. With mode=cursor the list is paged by next_cursor instead of page, sorted by created_at only, and counted only with with_total=true.
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string											true	"Bearer Token"
-//	@Param			page			query		int												false	"Page number (default is 1)"
-//	@Param			limit			query		int												false	"Number of users per page (default is 10)"
-//	@Success		200				{object}	common.ResponseNormal{data=[]models.Account}	"List of users"
-//	@Failure		400				{object}	common.ResponseError							"Invalid query parameters"
-//	@Failure		500				{object}	common.ResponseError							"Internal server error"
+//	@Param			Authorization	header		string												true	"Bearer Token"
+//	@Param			mode			query		string												false	"Paging mode (default is page)"	Enums(page, cursor)
+//	@Param			page			query		int													false	"Page number (default is 1)"
+//	@Param			cursor			query		string												false	"next_cursor of the previous page, implies mode=cursor"
+//	@Param			with_total		query		bool												false	"Count the total in cursor mode"
+//	@Param			limit			query		int													false	"Number of users per page (default is 10, at most 100)"
+//	@Param			sort			query		string												false	"Sort field (default is created_at)"	Enums(created_at, email, role)
+//	@Param			order			query		string												false	"Sort direction (default is desc)"		Enums(asc, desc)
+//	@Param			status			query		string												false	"Account status"						Enums(active, locked)
+//	@Param			role			query		string												false	"Account role"							Enums(admin, user, expert, support)
+//	@Param			is_verified		query		bool												false	"Email verification state"
+//	@Param			created_from	query		string												false	"Created at or after (RFC 3339)"
+//	@Param			created_to		query		string												false	"Created at or before (RFC 3339)"
+//	@Param			search			query		string												false	"Part of the email"
+//	@Success		200				{object}	common.ResponseNormal{data=[]dto.AccountResponse}	"List of users"
+//	@Failure		400				{object}	common.ResponseError								"Invalid query parameters"
+//	@Failure		500				{object}	common.ResponseError								"Internal server error"
 //	@Router			/admin/users [get]
 func (h *UserHandler) GetListUserHandler(ctx *gin.Context) {
 	var paging common.Paging
-	if err := ctx.ShouldBindQuery(&paging); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.NewResponseError(common.ErrBadRequestShouldBind))
+	if !bindQuery(ctx, &paging) {
+		return
+	}
+
+	var filter common.AccountFilter
+	if !bindQuery(ctx, &filter) {
 		return
 	}
 
-	accounts, err := h.userService.GetListAccounts(
-		ctx,
-		&paging,
-		map[string]interface{}{
-			"account_status": true,   // Only get active accounts
-			"role":           "user", // Only get user accounts
-			"is_verified":    true,   // Only get verified accounts
-		},
-	)
+	accounts, err := h.userService.GetListAccounts(ctx, &paging, &filter)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponsePaging("Get list accounts successfully", accounts, paging))
+	ctx.JSON(http.StatusOK, common.NewResponsePaging("Get list accounts successfully", dto.NewAccountResponses(accounts), paging))
 }
 
 // GetUserById godoc
@@ -127,12 +128,12 @@ func (h *UserHandler) GetListUserHandler(ctx *gin.Context) {
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string										true	"Bearer Token"
-//	@Param			id				path		string										true	"User ID"
-//	@Success		200				{object}	common.ResponseNormal{data=models.Account}	"User details"
-//	@Failure		400				{object}	common.ResponseError						"Invalid user ID"
-//	@Failure		404				{object}	common.ResponseError						"User not found"
-//	@Failure		500				{object}	common.ResponseError						"Internal server error"
+//	@Param			Authorization	header		string											true	"Bearer Token"
+//	@Param			id				path		string											true	"User ID"
+//	@Success		200				{object}	common.ResponseNormal{data=dto.AccountResponse}	"User details"
+//	@Failure		400				{object}	common.ResponseError							"Invalid user ID"
+//	@Failure		404				{object}	common.ResponseError							"User not found"
+//	@Failure		500				{object}	common.ResponseError							"Internal server error"
 //	@Router			/admin/user/{id} [get]
 func (h *UserHandler) GetUserByIdHandler(ctx *gin.Context) {
 	userId := ctx.Param("id")
@@ -148,24 +149,27 @@ func (h *UserHandler) GetUserByIdHandler(ctx *gin.Context) {
 	}
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get user by ID successfully", account))
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get user by ID successfully", dto.NewAccountResponse(account)))
 }
 
 // LockUserAccount godoc
 //	@Summary		Lock user account
-//	@Description	Lock user account by user ID
+//	@Description	Lock user account by user ID, optionally with a reason and an end time for a temporary suspension. The user loses API access immediately.
 //	@Tags			User
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string					true	"Bearer Token"
-//	@Param			id				path		string					true	"User ID"
-//	@Success		200				{object}	common.ResponseNormal	"User account locked successfully"
-//	@Failure		400				{object}	common.ResponseError	"Invalid user ID"
-//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Param			Authorization	header		string						true	"Bearer Token"
+//	@Param			id				path		string						true	"User ID"
+//	@Param			request			body		common.RequestLockAccount	false	"Lock reason and end time"
+//	@Success		200				{object}	common.ResponseNormal		"User account locked successfully"
+//	@Failure		400				{object}	common.ResponseError		"Invalid user ID"
+//	@Failure		403				{object}	common.ResponseError		"Permission denied or the role of the user outranks yours"
+//	@Failure		404				{object}	common.ResponseError		"User not found"
+//	@Failure		500				{object}	common.ResponseError		"Internal server error"
 //	@Router			/admin/user/{id}/lock [patch]
 func (h *UserHandler) LockUserAccountHandler(ctx *gin.Context) {
 	userId := ctx.Param("id")
@@ -174,8 +178,19 @@ func (h *UserHandler) LockUserAccountHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.userService.LockAccount(ctx, userId); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	// The body is optional: a lock without reason and end time is permanent
+	var lockRequest common.RequestLockAccount
+	if err := ctx.ShouldBindJSON(&lockRequest); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(common.ErrInvalidRequestBody)
+		return
+	}
+
+	if !validateRequest(ctx, &lockRequest) {
+		return
+	}
+
+	if err := h.userService.LockAccount(ctx, userId, actorFromContext(ctx), &lockRequest); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -192,6 +207,8 @@ func (h *UserHandler) LockUserAccountHandler(ctx *gin.Context) {
 //	@Param			id				path		string					true	"User ID"
 //	@Success		200				{object}	common.ResponseNormal	"User account unlocked successfully"
 //	@Failure		400				{object}	common.ResponseError	"Invalid user ID"
+//	@Failure		403				{object}	common.ResponseError	"Permission denied or the role of the user outranks yours"
+//	@Failure		404				{object}	common.ResponseError	"User not found"
 //	@Failure		500				{object}	common.ResponseError	"Internal server error"
 //	@Router			/admin/user/{id}/unlock [patch]
 func (h *UserHandler) UnlockUserAccountHandler(ctx *gin.Context) {
@@ -201,10 +218,77 @@ func (h *UserHandler) UnlockUserAccountHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.userService.UnlockAccount(ctx, userId); err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.NewResponseError(err.Error()))
+	if err := h.userService.UnlockAccount(ctx, userId, actorFromContext(ctx)); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, common.NewResponseNormal("User account unlocked successfully", nil))
 }
+
+// GetUserStatusHistory godoc
+//	@Summary		Get account status history
+//	@Description	Get every lock and unlock of a user account, newest first
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string														true	"Bearer Token"
+//	@Param			id				path		string														true	"User ID"
+//	@Success		200				{object}	common.ResponseNormal{data=[]models.AccountStatusHistory}	"Account status history"
+//	@Failure		400				{object}	common.ResponseError										"Invalid user ID"
+//	@Failure		404				{object}	common.ResponseError										"User not found"
+//	@Failure		500				{object}	common.ResponseError										"Internal server error"
+//	@Router			/admin/user/{id}/status-history [get]
+func (h *UserHandler) GetUserStatusHistoryHandler(ctx *gin.Context) {
+	userId := ctx.Param("id")
+	if userId == "" {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("User ID is required"))
+		return
+	}
+
+	histories, err := h.userService.GetAccountStatusHistory(ctx, userId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Get account status history successfully", histories))
+}
+
+// ClearUserLoginLockout godoc
+//	@Summary		Clear login lockout
+//	@Description	Reset the failed-login counter of a user account and lift the temporary login lock
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Bearer Token"
+//	@Param			id				path		string					true	"User ID"
+//	@Success		200				{object}	common.ResponseNormal	"Login lockout cleared successfully"
+//	@Failure		400				{object}	common.ResponseError	"Invalid user ID"
+//	@Failure		403				{object}	common.ResponseError	"Permission denied or the role of the user outranks yours"
+//	@Failure		404				{object}	common.ResponseError	"User not found"
+//	@Failure		500				{object}	common.ResponseError	"Internal server error"
+//	@Router			/admin/user/{id}/login-lockout [delete]
+func (h *UserHandler) ClearUserLoginLockoutHandler(ctx *gin.Context) {
+	userId := ctx.Param("id")
+	if userId == "" {
+		ctx.JSON(http.StatusBadRequest, common.NewResponseError("User ID is required"))
+		return
+	}
+
+	if err := h.userService.ClearLoginLockout(ctx, userId, actorFromContext(ctx)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponseNormal("Login lockout cleared successfully", nil))
+}
+
+// actorFromContext is the account of the request, as set by
+// JWTAuthMiddleware.
+func actorFromContext(ctx *gin.Context) services.Actor {
+	return services.Actor{
+		UserID: ctx.GetString("userID"),
+		Role:   ctx.GetString("role"),
+	}
+}
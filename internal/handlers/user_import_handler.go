@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserImportHandler struct {
+	userImportService services.UserImportService
+}
+
+func NewUserImportHandler(userImportService services.UserImportService) *UserImportHandler {
+	return &UserImportHandler{userImportService: userImportService}
+}
+
+// ImportUsers godoc
+//	@Summary		Import users from CSV
+//	@Description	Create accounts with their profiles from a CSV file with the header row email, role, full_name, date_of_birth, gender. role is optional (admin, user, expert or support, default user) and cannot rank above the role of the importing account, date_of_birth is YYYY-MM-DD and gender is male, female, nam or nữ. Every row is checked and the failed ones are reported with their line and errors, in the language of Accept-Language. With dry_run=true nothing is created.
+//	@Tags			User
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			Authorization	header		string													true	"Bearer Token"
+//	@Param			file			formData	file													true	"CSV file (max 5MB, 5000 rows)"
+//	@Param			dry_run			query		bool													false	"Only check the rows"
+//	@Param			notify			query		string													false	"Email sent to every created account"	Enums(welcome, set_password)
+//	@Success		200				{object}	common.ResponseNormal{data=services.UserImportResult}	"Import result"
+//	@Failure		400				{object}	common.ResponseError									"Invalid file or query parameters"
+//	@Failure		500				{object}	common.ResponseError									"Internal server error"
+//	@Router			/admin/users/import [post]
+func (h *UserImportHandler) ImportUsersHandler(ctx *gin.Context) {
+	var importRequest common.RequestImportUsers
+	if !bindQuery(ctx, &importRequest) {
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.Error(services.ErrInvalidImportFile.WithMessage("thiếu tệp CSV"))
+		return
+	}
+	if fileHeader.Size > services.MaxImportFileSize {
+		ctx.Error(services.ErrInvalidImportFile.WithMessage(fmt.Sprintf("tệp CSV vượt quá %dMB", services.MaxImportFileSize>>20)))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.userImportService.ImportUsers(ctx, actorFromContext(ctx), file, &importRequest, ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	message := "Import users successfully"
+	if importRequest.DryRun {
+		message = "Check import file successfully"
+	}
+	ctx.JSON(http.StatusOK, common.NewResponseNormal(message, result))
+}
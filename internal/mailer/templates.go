@@ -0,0 +1,180 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+type Locale string
+
+const (
+	LocaleVietnamese Locale = "vi"
+	LocaleEnglish    Locale = "en"
+
+	DefaultLocale = LocaleVietnamese
+)
+
+// ParseLocale maps an account preference to a supported locale, falling
+// back to DefaultLocale.
+func ParseLocale(value string) Locale {
+	switch locale := Locale(strings.ToLower(strings.TrimSpace(value))); locale {
+	case LocaleVietnamese, LocaleEnglish:
+		return locale
+	default:
+		return DefaultLocale
+	}
+}
+
+type Template string
+
+const (
+	TemplateOTP           Template = "otp"
+	TemplateWelcome       Template = "welcome"
+	TemplateSecurityAlert Template = "security_alert"
+	TemplateSetPassword   Template = "set_password"
+)
+
+var (
+	templateNames = []Template{TemplateOTP, TemplateWelcome, TemplateSecurityAlert, TemplateSetPassword}
+	locales       = []Locale{LocaleVietnamese, LocaleEnglish}
+)
+
+// OTPData fills TemplateOTP. Purpose is a common.OTPPurpose value.
+type OTPData struct {
+	Code             string
+	Purpose          string
+	ExpiresInMinutes int
+}
+
+// WelcomeData fills TemplateWelcome.
+type WelcomeData struct {
+	Email string
+}
+
+// SetPasswordData fills TemplateSetPassword, sent to an account created by an
+// administrator, whose owner sets the first password through forgot-password.
+type SetPasswordData struct {
+	Email string
+}
+
+type SecurityEvent string
+
+const (
+	SecurityEventPasswordChanged SecurityEvent = "password_changed"
+	SecurityEventPasswordReset   SecurityEvent = "password_reset"
+	SecurityEventTokenReuse      SecurityEvent = "token_reuse"
+	SecurityEventMFAEnabled      SecurityEvent = "mfa_enabled"
+	SecurityEventMFADisabled     SecurityEvent = "mfa_disabled"
+)
+
+// SecurityAlertData fills TemplateSecurityAlert.
+type SecurityAlertData struct {
+	Event SecurityEvent
+	Time  time.Time
+}
+
+// DecodeData reads template data stored as JSON, for instance in the email
+// outbox, back into the data type of the template.
+func DecodeData(name Template, payload []byte) (any, error) {
+	var data any
+	switch name {
+	case TemplateOTP:
+		data = &OTPData{}
+	case TemplateWelcome:
+		data = &WelcomeData{}
+	case TemplateSecurityAlert:
+		data = &SecurityAlertData{}
+	case TemplateSetPassword:
+		data = &SetPasswordData{}
+	default:
+		return nil, fmt.Errorf("không có mẫu email %s", name)
+	}
+
+	if err := json.Unmarshal(payload, data); err != nil {
+		return nil, fmt.Errorf("đọc dữ liệu email thất bại: %w", err)
+	}
+	return data, nil
+}
+
+//go:embed templates
+var templateFS embed.FS
+
+type templateKey struct {
+	name   Template
+	locale Locale
+}
+
+// Renderer turns a template and its data into a Message. Each template has a
+// text file defining "subject" and "body", and an HTML file defining
+// "content" that is wrapped in the shared layout.
+type Renderer struct {
+	html map[templateKey]*htmltemplate.Template
+	text map[templateKey]*texttemplate.Template
+}
+
+// NewRenderer parses every template up front, so a broken template stops the
+// service at start-up rather than when the first email goes out.
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		html: make(map[templateKey]*htmltemplate.Template),
+		text: make(map[templateKey]*texttemplate.Template),
+	}
+
+	for _, locale := range locales {
+		for _, name := range templateNames {
+			key := templateKey{name: name, locale: locale}
+
+			html, err := htmltemplate.ParseFS(templateFS,
+				"templates/layout.html",
+				fmt.Sprintf("templates/%s/layout.html", locale),
+				fmt.Sprintf("templates/%s/%s.html", locale, name),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("đọc mẫu email %s (%s) thất bại: %w", name, locale, err)
+			}
+
+			text, err := texttemplate.ParseFS(templateFS, fmt.Sprintf("templates/%s/%s.txt", locale, name))
+			if err != nil {
+				return nil, fmt.Errorf("đọc mẫu email %s (%s) thất bại: %w", name, locale, err)
+			}
+
+			r.html[key] = html
+			r.text[key] = text
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Renderer) Render(name Template, locale Locale, to string, data any) (*Message, error) {
+	key := templateKey{name: name, locale: ParseLocale(string(locale))}
+	html, ok := r.html[key]
+	if !ok {
+		return nil, fmt.Errorf("không có mẫu email %s", name)
+	}
+	text := r.text[key]
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("tạo tiêu đề email thất bại: %w", err)
+	}
+	if err := text.ExecuteTemplate(&textBody, "body", data); err != nil {
+		return nil, fmt.Errorf("tạo nội dung email thất bại: %w", err)
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return nil, fmt.Errorf("tạo nội dung email thất bại: %w", err)
+	}
+
+	return &Message{
+		To:       to,
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: strings.TrimSpace(textBody.String()) + "\n",
+		HTMLBody: htmlBody.String(),
+	}, nil
+}
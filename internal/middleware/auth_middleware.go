@@ -2,52 +2,46 @@ package middleware
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
-	"DH52111659-api-quan-ly-suc-khoe/utils"
-	"net/http"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JWTAuthMiddleware(tokenService utils.TokenService, requireRoles ...string) gin.HandlerFunc {
+var (
+	ErrMissingAuthorization = common.NewUnauthorized("MISSING_AUTHORIZATION", "Authorization header is required")
+	ErrInvalidAuthorization = common.NewUnauthorized("INVALID_AUTHORIZATION", "Token must be in Bearer format")
+)
+
+// JWTAuthMiddleware authenticates the access token of the request. What the
+// account may do is checked by RequirePermission.
+func JWTAuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, common.NewResponseError("Authorization header is required"))
+			ctx.Error(ErrMissingAuthorization)
 			ctx.Abort()
 			return
 		}
 
 		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-			ctx.JSON(http.StatusUnauthorized, common.NewResponseError("Token must be in Bearer format"))
+			ctx.Error(ErrInvalidAuthorization)
+			ctx.Abort()
 			return
 		}
 
 		tokenString := authHeader[len("Bearer "):]
-		claims, err := tokenService.VerifyToken(tokenString)
+		claims, err := authService.AuthenticateAccessToken(ctx, tokenString)
 		if err != nil {
-			ctx.JSON(401, common.NewResponseError("Invalid token"))
-			ctx.Abort()
-			return
-		}
-
-		// Check if the user has the required role
-		roleIsValid := false
-		for _, role := range requireRoles {
-			if claims.Role == role {
-				roleIsValid = true
-				break
-			}
-		}
-
-		if !roleIsValid && len(requireRoles) > 0 {
-			ctx.JSON(http.StatusForbidden, common.NewResponseError("You do not have permission to access this resource"))
+			ctx.Error(err)
 			ctx.Abort()
 			return
 		}
 
-		// Store the user ID in the context for later use
+		// Store the user ID in the context for later use. The role is the
+		// current one of the account, read with its cached state.
 		ctx.Set("userID", claims.UserID)
 		ctx.Set("role", claims.Role)
+		ctx.Set("claims", claims)
 		ctx.Next()
 	}
 }
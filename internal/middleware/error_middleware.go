@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler answers for the handlers that report a failure with
+// ctx.Error instead of writing a response. An AppError gets the status of its
+// kind, any other error is logged and answered with a plain 500.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 {
+			return
+		}
+
+		err := ctx.Errors.Last().Err
+		// A streamed response can fail after it started, the status is sent
+		if ctx.Writer.Written() {
+			log.Printf("%s %s failed after the response started: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+			return
+		}
+
+		var appErr *common.AppError
+		if !errors.As(err, &appErr) {
+			log.Printf("%s %s failed: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+			appErr = common.ErrInternal
+		} else if appErr.Err != nil {
+			log.Printf("%s %s failed: %v", ctx.Request.Method, ctx.Request.URL.Path, appErr.Err)
+		}
+
+		if appErr.RetryAfter > 0 {
+			ctx.Header("Retry-After", strconv.Itoa(appErr.RetryAfterSeconds()))
+		}
+		ctx.JSON(appErr.Kind.HTTPStatus(), common.NewResponseAppError(appErr))
+	}
+}
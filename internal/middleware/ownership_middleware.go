@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireOwnerAccess checks with policy that the account of the request may
+// act on the resource owned by the account in the path parameter param. It
+// must run after JWTAuthMiddleware.
+func RequireOwnerAccess(
+	policy services.AccessPolicy,
+	resource services.OwnedResource,
+	action services.AccessAction,
+	param string,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		actor := services.Actor{
+			UserID: ctx.GetString("userID"),
+			Role:   ctx.GetString("role"),
+		}
+
+		if err := policy.Authorize(ctx, actor, resource, ctx.Param(param), action); err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
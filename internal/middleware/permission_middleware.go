@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrPermissionDenied = common.NewForbidden("PERMISSION_DENIED", "You do not have permission to access this resource")
+
+// RequirePermission lets the request through only when the role of the
+// account grants every one of permissions. It must run after
+// JWTAuthMiddleware, which sets the role.
+func RequirePermission(authorizationService services.AuthorizationService, permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString("role")
+		if role == "" {
+			ctx.Error(ErrPermissionDenied)
+			ctx.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			allowed, err := authorizationService.HasPermission(ctx, role, permission)
+			if err != nil {
+				ctx.Error(err)
+				ctx.Abort()
+				return
+			}
+
+			if !allowed {
+				ctx.Error(ErrPermissionDenied.WithDetails(map[string]interface{}{
+					"permission": permission,
+				}))
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
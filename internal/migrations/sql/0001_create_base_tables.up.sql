@@ -0,0 +1,33 @@
+-- Tables that existed before migrations were tracked. IF NOT EXISTS lets a
+-- database created by hand adopt this baseline.
+CREATE TABLE IF NOT EXISTS accounts (
+    id              UUID PRIMARY KEY,
+    email           VARCHAR(255) NOT NULL UNIQUE,
+    password_hash   VARCHAR(255) NOT NULL,
+    role            VARCHAR(20) NOT NULL DEFAULT 'user',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    is_verified     BOOLEAN NOT NULL DEFAULT false,
+    account_status  BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TABLE IF NOT EXISTS profiles (
+    user_id         UUID PRIMARY KEY REFERENCES accounts (id) ON DELETE CASCADE,
+    full_name       VARCHAR(255) NOT NULL,
+    day_of_birth    DATE NOT NULL,
+    gender          BOOLEAN NOT NULL DEFAULT true,
+    avatar_url      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS experts (
+    expert_id         SERIAL PRIMARY KEY,
+    full_name         VARCHAR(255) NOT NULL,
+    date_of_birth     DATE NOT NULL,
+    gender            BOOLEAN NOT NULL DEFAULT true,
+    telephone_number  VARCHAR(20),
+    email             VARCHAR(255) NOT NULL,
+    avatar_url        TEXT,
+    verified          BOOLEAN NOT NULL DEFAULT true,
+    is_deleted        BOOLEAN NOT NULL DEFAULT false,
+    account_id        UUID REFERENCES accounts (id) ON DELETE SET NULL
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_status_histories;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS lock_reason;
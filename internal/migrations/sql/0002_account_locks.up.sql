@@ -0,0 +1,16 @@
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS lock_reason VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS account_status_histories (
+    id              BIGSERIAL PRIMARY KEY,
+    account_id      UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    account_status  BOOLEAN NOT NULL,
+    reason          VARCHAR(255),
+    locked_until    TIMESTAMPTZ,
+    changed_by      UUID REFERENCES accounts (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_histories_account_id
+    ON account_status_histories (account_id, created_at DESC);
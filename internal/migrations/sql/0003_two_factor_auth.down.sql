@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS account_recovery_codes;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
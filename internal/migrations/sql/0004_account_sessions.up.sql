@@ -0,0 +1,15 @@
+-- id is the refresh token family of the login
+CREATE TABLE IF NOT EXISTS account_sessions (
+    id            UUID PRIMARY KEY,
+    account_id    UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    device_name   VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent    TEXT NOT NULL DEFAULT '',
+    ip_address    VARCHAR(45) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMPTZ NOT NULL,
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_sessions_account_id
+    ON account_sessions (account_id, last_used_at DESC);
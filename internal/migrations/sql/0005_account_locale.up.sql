@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'vi';
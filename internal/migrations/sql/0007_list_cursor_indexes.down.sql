@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_email_outbox_created_at_id;
+DROP INDEX IF EXISTS idx_accounts_created_at_id;
@@ -0,0 +1,6 @@
+-- Cursor pagination seeks on (created_at, id) in both directions
+CREATE INDEX IF NOT EXISTS idx_accounts_created_at_id
+    ON accounts (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_created_at_id
+    ON email_outbox (created_at, id);
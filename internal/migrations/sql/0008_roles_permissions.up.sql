@@ -0,0 +1,81 @@
+-- Roles and the permissions they grant. accounts.role names a row of roles.
+CREATE TABLE IF NOT EXISTS roles (
+    name         VARCHAR(20) PRIMARY KEY,
+    description  TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name         VARCHAR(50) PRIMARY KEY,
+    description  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role         VARCHAR(20) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission   VARCHAR(50) NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Administrator'),
+    ('expert', 'Health expert'),
+    ('user', 'Member'),
+    ('support', 'Customer support')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('user:read', 'List and view accounts'),
+    ('user:create', 'Create accounts'),
+    ('user:import', 'Import accounts from CSV'),
+    ('user:export', 'Export accounts'),
+    ('user:lock', 'Lock and unlock accounts, clear login lockouts'),
+    ('user:reset-password', 'Set the password of an account'),
+    ('session:read:any', 'List the sessions of any account'),
+    ('session:revoke:any', 'Revoke the sessions of any account'),
+    ('expert:create', 'Create experts'),
+    ('profile:read:any', 'View the profile of any account'),
+    ('profile:update:any', 'Update the profile of any account'),
+    ('email:read', 'View the email outbox'),
+    ('email:retry', 'Retry failed emails'),
+    ('role:manage', 'Grant and revoke the permissions of roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT (role, permission) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('support', 'user:read'),
+    ('support', 'user:lock'),
+    ('support', 'session:read:any'),
+    ('support', 'session:revoke:any'),
+    ('support', 'profile:read:any'),
+    ('support', 'email:read'),
+    ('support', 'email:retry')
+ON CONFLICT (role, permission) DO NOTHING;
+
+-- Roles written in another case are normalised, and accounts with a role
+-- that is not seeded fall back to user, the role granting nothing, so the
+-- foreign key can be added. The fallen back accounts are reported.
+UPDATE accounts SET role = lower(trim(role))
+WHERE role <> lower(trim(role))
+  AND lower(trim(role)) IN (SELECT name FROM roles);
+
+DO $$
+DECLARE
+    unknown_roles INTEGER;
+BEGIN
+    UPDATE accounts SET role = 'user'
+    WHERE role NOT IN (SELECT name FROM roles);
+    GET DIAGNOSTICS unknown_roles = ROW_COUNT;
+    IF unknown_roles > 0 THEN
+        RAISE NOTICE '% account(s) with an unknown role were given the user role', unknown_roles;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_accounts_role') THEN
+        ALTER TABLE accounts
+            ADD CONSTRAINT fk_accounts_role FOREIGN KEY (role) REFERENCES roles (name);
+    END IF;
+END
+$$;
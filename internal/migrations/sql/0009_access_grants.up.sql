@@ -0,0 +1,10 @@
+-- An account lets an expert read its profile and health records
+CREATE TABLE IF NOT EXISTS access_grants (
+    owner_id     UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    grantee_id   UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (owner_id, grantee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_grants_grantee_id
+    ON access_grants (grantee_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_accounts_lower_email;
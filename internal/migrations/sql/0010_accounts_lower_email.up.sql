@@ -0,0 +1,3 @@
+-- Emails are looked up whatever their case
+CREATE INDEX IF NOT EXISTS idx_accounts_lower_email
+    ON accounts (lower(email));
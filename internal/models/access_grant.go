@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessGrant lets the grantee, an expert, read the profile and the health
+// records of the owner until the owner revokes it.
+type AccessGrant struct {
+	OwnerID 		uuid.UUID 	`json:"owner_id" gorm:"column:owner_id;primaryKey"`
+	GranteeID 		uuid.UUID 	`json:"grantee_id" gorm:"column:grantee_id;primaryKey"`
+	CreatedAt 		*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
+}
+
+func (AccessGrant) TableName() string {
+	return "access_grants"
+}
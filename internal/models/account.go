@@ -9,12 +9,18 @@ import (
 
 type Account struct {
 	ID 				uuid.UUID 	`json:"id,omitempty" gorm:"column:id;primaryKey"`
-	Email 			string		`json:"email" gorm:"column:email;unique;not null" validate:"required,email"`
-	Password 		string 		`json:"password" gorm:"column:password_hash;not null" validate:"required,min=8,max=100"`
+	Email 			string		`json:"email" gorm:"column:email;unique;not null"`
+	Password 		string 		`json:"-" gorm:"column:password_hash;not null"`
 	Role 			string 		`json:"role,omitempty" gorm:"column:role;default:'user'"`
 	CreatedAt 		*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
 	IsVerified 		bool 		`json:"is_verified,omitempty" gorm:"column:is_verified;default:false"`
 	AccountStatus 	bool 		`json:"account_status,omitempty" gorm:"column:account_status;default:true"`
+	LockReason 		*string 	`json:"lock_reason,omitempty" gorm:"column:lock_reason"`
+	LockedUntil 	*time.Time 	`json:"locked_until,omitempty" gorm:"column:locked_until"`
+	TOTPSecret 		string 		`json:"-" gorm:"column:totp_secret"`
+	TOTPEnabled 	bool 		`json:"totp_enabled,omitempty" gorm:"column:totp_enabled;default:false"`
+	TOTPEnabledAt 	*time.Time 	`json:"totp_enabled_at,omitempty" gorm:"column:totp_enabled_at"`
+	Locale 			string 		`json:"locale,omitempty" gorm:"column:locale;default:'vi'"`
 }
 
 func (Account) TableName() string {
@@ -28,10 +34,73 @@ func (a *Account) BeforeCreate(tx *gorm.DB) error {
     return nil
 }
 
-type AccountCreate struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8,max=100"`
-	Role     string `json:"role,omitempty" validate:"omitempty,oneof=admin user"`
-	IsVerified bool   `json:"is_verified,omitempty" validate:"omitempty"`
+// IsLocked reports whether the account is locked at the given time.
+// A temporary suspension stops applying once LockedUntil has passed.
+func (a *Account) IsLocked(now time.Time) bool {
+	if a.AccountStatus {
+		return false
+	}
+
+	if a.LockedUntil != nil && !now.Before(*a.LockedUntil) {
+		return false
+	}
+
+	return true
+}
+
+// AccountState is the part of an account checked on every authenticated
+// request. It is small enough to be cached. Role is the current role of the
+// account, which decides its permissions whatever the token says.
+type AccountState struct {
+	Role 		string 		`json:"role"`
+	Verified 	bool 		`json:"verified"`
+	Locked 		bool 		`json:"locked"`
+	LockReason 	string 		`json:"lock_reason,omitempty"`
+	LockedUntil *time.Time 	`json:"locked_until,omitempty"`
 }
 
+// IsLocked reports whether the cached lock still applies at the given time.
+func (s *AccountState) IsLocked(now time.Time) bool {
+	return s.Locked && (s.LockedUntil == nil || now.Before(*s.LockedUntil))
+}
+
+func NewAccountState(account *Account, now time.Time) *AccountState {
+	state := &AccountState{
+		Role:     account.Role,
+		Verified: account.IsVerified,
+		Locked:   account.IsLocked(now),
+	}
+
+	if state.Locked {
+		if account.LockReason != nil {
+			state.LockReason = *account.LockReason
+		}
+		state.LockedUntil = account.LockedUntil
+	}
+
+	return state
+}
+
+// AccountExport is an account joined with its profile, for the admin export.
+// It has no password field, so the hash cannot end up in a report. The
+// profile fields are nil for an account without a profile.
+type AccountExport struct {
+	ID 				uuid.UUID 	`gorm:"column:id"`
+	Email 			string 		`gorm:"column:email"`
+	Role 			string 		`gorm:"column:role"`
+	IsVerified 		bool 		`gorm:"column:is_verified"`
+	AccountStatus 	bool 		`gorm:"column:account_status"`
+	LockReason 		*string 	`gorm:"column:lock_reason"`
+	LockedUntil 	*time.Time 	`gorm:"column:locked_until"`
+	TOTPEnabled 	bool 		`gorm:"column:totp_enabled"`
+	Locale 			string 		`gorm:"column:locale"`
+	CreatedAt 		time.Time 	`gorm:"column:created_at"`
+	FullName 		*string 	`gorm:"column:full_name"`
+	DayOfBirth 		*time.Time 	`gorm:"column:day_of_birth"`
+	Gender 			*bool 		`gorm:"column:gender"`
+}
+
+func (a *AccountExport) IsLocked(now time.Time) bool {
+	account := Account{AccountStatus: a.AccountStatus, LockedUntil: a.LockedUntil}
+	return account.IsLocked(now)
+}
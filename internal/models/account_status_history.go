@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountStatusHistory records every lock and unlock of an account.
+type AccountStatusHistory struct {
+	ID 				int64 		`json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	AccountID 		uuid.UUID 	`json:"account_id" gorm:"column:account_id;not null"`
+	AccountStatus 	bool 		`json:"account_status" gorm:"column:account_status;not null"`
+	Reason 			*string 	`json:"reason,omitempty" gorm:"column:reason"`
+	LockedUntil 	*time.Time 	`json:"locked_until,omitempty" gorm:"column:locked_until"`
+	ChangedBy 		*uuid.UUID 	`json:"changed_by,omitempty" gorm:"column:changed_by"`
+	CreatedAt 		*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
+}
+
+func (AccountStatusHistory) TableName() string {
+	return "account_status_histories"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead"
+	EmailStatusExpired = "expired"
+)
+
+// EmailOutbox is an email waiting for, or done with, delivery by the outbox
+// worker. It is written in the same transaction as the change it announces.
+// Payload holds the template data and is cleared once it is no longer
+// needed, since it may contain a one-time code.
+type EmailOutbox struct {
+	ID 				int64 		`json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Template 		string 		`json:"template" gorm:"column:template;not null"`
+	Locale 			string 		`json:"locale" gorm:"column:locale;not null"`
+	Recipient 		string 		`json:"recipient" gorm:"column:recipient;not null"`
+	Payload 		string 		`json:"-" gorm:"column:payload;type:jsonb;not null"`
+	Status 			string 		`json:"status" gorm:"column:status;not null;default:'pending'"`
+	Attempts 		int 		`json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt 	time.Time 	`json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	ExpiresAt 		*time.Time 	`json:"expires_at,omitempty" gorm:"column:expires_at"`
+	LastError 		*string 	`json:"last_error,omitempty" gorm:"column:last_error"`
+	SentAt 			*time.Time 	`json:"sent_at,omitempty" gorm:"column:sent_at"`
+	CreatedAt 		time.Time 	`json:"created_at" gorm:"column:created_at"`
+	UpdatedAt 		time.Time 	`json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
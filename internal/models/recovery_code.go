@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode is a single-use two-factor backup code. Only its hash is kept.
+type RecoveryCode struct {
+	ID 			int64 		`json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	AccountID 	uuid.UUID 	`json:"account_id" gorm:"column:account_id;not null"`
+	CodeHash 	string 		`json:"-" gorm:"column:code_hash;not null"`
+	UsedAt 		*time.Time 	`json:"used_at,omitempty" gorm:"column:used_at"`
+	CreatedAt 	*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "account_recovery_codes"
+}
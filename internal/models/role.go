@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Roles seeded by the migrations. Accounts can only hold a role of the roles
+// table.
+const (
+	RoleAdmin   = "admin"
+	RoleExpert  = "expert"
+	RoleUser    = "user"
+	RoleSupport = "support"
+)
+
+// roleRanks orders the roles for the admin actions on other accounts, such as
+// locking them or revoking their sessions.
+var roleRanks = map[string]int{
+	RoleUser:    1,
+	RoleExpert:  2,
+	RoleSupport: 3,
+	RoleAdmin:   4,
+}
+
+// RoleOutranks reports whether role ranks above other. A role missing from
+// roleRanks ranks below every seeded role.
+func RoleOutranks(role, other string) bool {
+	return roleRanks[role] > roleRanks[other]
+}
+
+// Permissions checked by the API. A role grants the permissions listed for it
+// in role_permissions; the ":any" ones act on the resources of other
+// accounts.
+const (
+	PermissionUserRead          = "user:read"
+	PermissionUserCreate        = "user:create"
+	PermissionUserImport        = "user:import"
+	PermissionUserExport        = "user:export"
+	PermissionUserLock          = "user:lock"
+	PermissionUserResetPassword = "user:reset-password"
+	PermissionSessionReadAny    = "session:read:any"
+	PermissionSessionRevokeAny  = "session:revoke:any"
+	PermissionExpertCreate      = "expert:create"
+	PermissionProfileReadAny    = "profile:read:any"
+	PermissionProfileUpdateAny  = "profile:update:any"
+	PermissionEmailRead         = "email:read"
+	PermissionEmailRetry        = "email:retry"
+	PermissionRoleManage        = "role:manage"
+)
+
+// Role is a role of the roles table. Permissions is filled by the service
+// from role_permissions.
+type Role struct {
+	Name 			string 		`json:"name" gorm:"column:name;primaryKey"`
+	Description 	string 		`json:"description" gorm:"column:description"`
+	CreatedAt 		*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
+	Permissions 	[]string 	`json:"permissions" gorm:"-"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+type Permission struct {
+	Name 			string 		`json:"name" gorm:"column:name;primaryKey"`
+	Description 	string 		`json:"description" gorm:"column:description"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RolePermission grants a permission to every account of a role.
+type RolePermission struct {
+	Role 			string 		`json:"role" gorm:"column:role;primaryKey"`
+	Permission 		string 		`json:"permission" gorm:"column:permission;primaryKey"`
+	CreatedAt 		*time.Time 	`json:"created_at,omitempty" gorm:"column:created_at"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
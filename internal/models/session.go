@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login of an account on a device. Its ID is the refresh
+// token family ID, so revoking the session revokes the family.
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"column:id;primaryKey"`
+	AccountID  uuid.UUID  `json:"account_id" gorm:"column:account_id;not null"`
+	DeviceName string     `json:"device_name" gorm:"column:device_name"`
+	UserAgent  string     `json:"user_agent" gorm:"column:user_agent"`
+	IPAddress  string     `json:"ip_address" gorm:"column:ip_address"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at"`
+	LastUsedAt time.Time  `json:"last_used_at" gorm:"column:last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	Current    bool       `json:"current" gorm:"-"`
+}
+
+func (Session) TableName() string {
+	return "account_sessions"
+}
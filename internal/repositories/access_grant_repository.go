@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccessGrantRepository interface {
+	Create(ctx context.Context, grant *models.AccessGrant) error
+	Delete(ctx context.Context, ownerID, granteeID string) (bool, error)
+	Exists(ctx context.Context, ownerID, granteeID string) (bool, error)
+	GetListByOwnerId(ctx context.Context, ownerID string) ([]*models.AccessGrant, error)
+}
+
+type AccessGrantRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewAccessGrantRepoImpl(db *gorm.DB) *AccessGrantRepoImpl {
+	return &AccessGrantRepoImpl{DB: db}
+}
+
+// Create keeps the existing grant when the owner grants the same expert again.
+func (repo *AccessGrantRepoImpl) Create(ctx context.Context, grant *models.AccessGrant) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.AccessGrant{}.TableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Omit("created_at").
+		Create(grant).Error
+}
+
+// Delete reports whether there was a grant to remove.
+func (repo *AccessGrantRepoImpl) Delete(ctx context.Context, ownerID, granteeID string) (bool, error) {
+	result := dbFromContext(ctx, repo.DB).
+		Where("owner_id = ? AND grantee_id = ?", ownerID, granteeID).
+		Delete(&models.AccessGrant{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *AccessGrantRepoImpl) Exists(ctx context.Context, ownerID, granteeID string) (bool, error) {
+	var count int64
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.AccessGrant{}.TableName()).
+		Where("owner_id = ? AND grantee_id = ?", ownerID, granteeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (repo *AccessGrantRepoImpl) GetListByOwnerId(ctx context.Context, ownerID string) ([]*models.AccessGrant, error) {
+	var grants []*models.AccessGrant
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.AccessGrant{}.TableName()).
+		Where("owner_id = ?", ownerID).
+		Order("created_at DESC").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
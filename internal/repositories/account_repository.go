@@ -4,17 +4,40 @@ import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"context"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type AccountRepository interface {
 	Create(ctx context.Context, account *models.Account) (error)
+	CreateBatch(ctx context.Context, accounts []*models.Account) error
 	Update(ctx context.Context, cond map[string]interface{}, updateValue map[string]interface{}) (error)
 	GetByEmail(ctx context.Context, email string) (*models.Account, error)
+	GetExistingEmails(ctx context.Context, emails []string) ([]string, error)
 	GetAccountById(ctx context.Context, id string) (*models.Account, error)
-	GetDB() *gorm.DB
-	GetListAccount(ctx context.Context, paging *common.Paging,cond map[string]interface{}) ([]*models.Account, error)
+	GetListAccount(ctx context.Context, paging *common.Paging, filter *common.AccountFilter) ([]*models.Account, error)
+	ExportAccounts(ctx context.Context, filter *common.AccountFilter, fn func(row *models.AccountExport) error) error
+}
+
+// accountExportColumns are the columns of models.AccountExport. They are
+// listed one by one so a new secret column is never exported by accident.
+var accountExportColumns = []string{
+	"accounts.id",
+	"accounts.email",
+	"accounts.role",
+	"accounts.is_verified",
+	"accounts.account_status",
+	"accounts.lock_reason",
+	"accounts.locked_until",
+	"accounts.totp_enabled",
+	"accounts.locale",
+	"accounts.created_at",
+	"profiles.full_name",
+	"profiles.day_of_birth",
+	"profiles.gender",
 }
 
 
@@ -31,7 +54,7 @@ func NewAccountRepoImpl(db *gorm.DB) *AccountRepoImpl {
 }
 
 func(repo *AccountRepoImpl) Create(ctx context.Context, account *models.Account) (error) {
-	if err := repo.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, repo.DB).
 		Table(models.Account{}.TableName()).
 		Create(account).Error; err != nil {
 		return err 
@@ -40,20 +63,29 @@ func(repo *AccountRepoImpl) Create(ctx context.Context, account *models.Account)
 	return nil
 }
 
+// CreateBatch inserts the accounts with one statement.
+func(repo *AccountRepoImpl) CreateBatch(ctx context.Context, accounts []*models.Account) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.Account{}.TableName()).
+		Create(&accounts).Error
+}
+
 func(repo *AccountRepoImpl) Update(ctx context.Context, cond map[string]interface{}, updateValue map[string]interface{}) (error){
-	if err := repo.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, repo.DB).
 		Table(models.Account{}.TableName()).Where(cond).Updates(updateValue).Error; err != nil {
 		return err 
 	}
 	return nil
 }
 
+// GetByEmail matches the email whatever its case, accounts created before
+// emails were normalised may hold upper case letters.
 func(repo *AccountRepoImpl) GetByEmail(ctx context.Context, email string) (*models.Account, error){
 	var account models.Account
 
-	if err := repo.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, repo.DB).
 		Table(models.Account{}.TableName()).
-		Where("email = ?", email).
+		Where("lower(email) = lower(?)", email).
 		First(&account).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil // Not found
@@ -64,10 +96,25 @@ func(repo *AccountRepoImpl) GetByEmail(ctx context.Context, email string) (*mode
 	return &account, nil
 }
 
+// GetExistingEmails returns, in lower case, the emails that already belong to
+// an account whatever their case. emails must be in lower case.
+func(repo *AccountRepoImpl) GetExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	var existing []string
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Account{}.TableName()).
+		Where("lower(email) IN ?", emails).
+		Pluck("lower(email)", &existing).Error; err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
 func(repo *AccountRepoImpl) GetAccountById(ctx context.Context, id string) (*models.Account, error) {
 	var account models.Account
 
-	if err := repo.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, repo.DB).
 		Table(models.Account{}.TableName()).
 		Where("id = ?", id).
 		First(&account).Error; err != nil {
@@ -80,25 +127,85 @@ func(repo *AccountRepoImpl) GetAccountById(ctx context.Context, id string) (*mod
 	return &account, nil
 }
 
-func (repo *AccountRepoImpl) GetDB() *gorm.DB{
-	return repo.DB
+func(repo *AccountRepoImpl) GetListAccount(ctx context.Context, paging *common.Paging, filter *common.AccountFilter) ([]*models.Account, error) {
+	query := filterAccounts(dbFromContext(ctx, repo.DB).Table(models.Account{}.TableName()), filter, time.Now())
+	return findPage(query, paging, accountCursorKey, parseAccountID)
 }
 
-func(repo *AccountRepoImpl) GetListAccount(ctx context.Context, paging *common.Paging,cond map[string]interface{}) ([]*models.Account, error) {
-	var accounts []*models.Account
+func accountCursorKey(account *models.Account) (time.Time, string) {
+	var createdAt time.Time
+	if account.CreatedAt != nil {
+		createdAt = *account.CreatedAt
+	}
+	return createdAt, account.ID.String()
+}
 
-	query := repo.DB.WithContext(ctx).Table(models.Account{}.TableName()).Where(cond)
-	if err := query.Count(&paging.Total).Error; err != nil {
-		return nil, err
+func parseAccountID(id string) (interface{}, error) {
+	return uuid.Parse(id)
+}
+
+// ExportAccounts calls fn with every account matching filter joined with its
+// profile, newest first. Rows are read one at a time, so memory does not grow
+// with the number of accounts.
+func(repo *AccountRepoImpl) ExportAccounts(ctx context.Context, filter *common.AccountFilter, fn func(row *models.AccountExport) error) error {
+	query := filterAccounts(dbFromContext(ctx, repo.DB).Table(models.Account{}.TableName()), filter, time.Now()).
+		Select(accountExportColumns).
+		Joins("LEFT JOIN profiles ON profiles.user_id = accounts.id").
+		Order("accounts.created_at DESC, accounts.id DESC")
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	if err := query.
-		Order("created_at DESC").
-		Offset((paging.Page-1)*paging.Limit).
-		Limit(paging.Limit).
-		Find(&accounts).Error; err != nil {
-		return nil, err
+	for rows.Next() {
+		var row models.AccountExport
+		if err := query.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// filterAccounts applies the filter, a lock whose locked_until has passed
+// counts as active like in Account.IsLocked.
+func filterAccounts(query *gorm.DB, filter *common.AccountFilter, now time.Time) *gorm.DB {
+	switch filter.Status {
+	case common.AccountStatusActive:
+		query = query.Where("accounts.account_status = ? OR accounts.locked_until <= ?", true, now)
+	case common.AccountStatusLocked:
+		query = query.Where("accounts.account_status = ? AND (accounts.locked_until IS NULL OR accounts.locked_until > ?)", false, now)
+	}
+
+	if filter.Role != "" {
+		query = query.Where("accounts.role = ?", filter.Role)
+	}
+
+	if filter.IsVerified != nil {
+		query = query.Where("accounts.is_verified = ?", *filter.IsVerified)
+	}
+
+	if filter.CreatedFrom != nil {
+		query = query.Where("accounts.created_at >= ?", *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		query = query.Where("accounts.created_at <= ?", *filter.CreatedTo)
 	}
-	return accounts, nil
+
+	if filter.Search != "" {
+		query = query.Where("accounts.email ILIKE ?", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	return query
 }
 
+// escapeLike makes the wildcards of a search term match literally.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
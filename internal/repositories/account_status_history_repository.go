@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type AccountStatusHistoryRepository interface {
+	Create(ctx context.Context, history *models.AccountStatusHistory) error
+	GetListByAccountId(ctx context.Context, accountID string) ([]*models.AccountStatusHistory, error)
+}
+
+type AccountStatusHistoryRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewAccountStatusHistoryRepoImpl(db *gorm.DB) *AccountStatusHistoryRepoImpl {
+	return &AccountStatusHistoryRepoImpl{DB: db}
+}
+
+func (repo *AccountStatusHistoryRepoImpl) Create(ctx context.Context, history *models.AccountStatusHistory) error {
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.AccountStatusHistory{}.TableName()).
+		Create(history).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *AccountStatusHistoryRepoImpl) GetListByAccountId(ctx context.Context, accountID string) ([]*models.AccountStatusHistory, error) {
+	var histories []*models.AccountStatusHistory
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.AccountStatusHistory{}.TableName()).
+		Where("account_id = ?", accountID).
+		Order("created_at DESC").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
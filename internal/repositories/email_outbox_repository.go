@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EmailOutboxRepository interface {
+	Create(ctx context.Context, email *models.EmailOutbox) error
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.EmailOutbox, error)
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	MarkDead(ctx context.Context, id int64, lastError string) error
+	MarkExpired(ctx context.Context, id int64) error
+	Retry(ctx context.Context, id int64, now time.Time) (bool, error)
+	GetById(ctx context.Context, id int64) (*models.EmailOutbox, error)
+	GetList(ctx context.Context, paging *common.Paging, cond map[string]interface{}) ([]*models.EmailOutbox, error)
+}
+
+type EmailOutboxRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewEmailOutboxRepoImpl(db *gorm.DB) *EmailOutboxRepoImpl {
+	return &EmailOutboxRepoImpl{DB: db}
+}
+
+func (repo *EmailOutboxRepoImpl) Create(ctx context.Context, email *models.EmailOutbox) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.EmailOutbox{}.TableName()).
+		Create(email).Error
+}
+
+// ClaimDue picks pending emails whose next attempt is due and pushes their
+// next attempt lease into the future, so concurrent workers skip them and an
+// email whose worker died is picked up again once the lease runs out.
+func (repo *EmailOutboxRepoImpl) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.EmailOutbox, error) {
+	var emails []*models.EmailOutbox
+
+	if err := dbFromContext(ctx, repo.DB).Raw(`
+		UPDATE email_outbox
+		SET attempts = attempts + 1, next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), now, models.EmailStatusPending, now, limit,
+	).Scan(&emails).Error; err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// MarkSent also clears the payload, which is not needed any more.
+func (repo *EmailOutboxRepoImpl) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	return repo.update(ctx, id, map[string]interface{}{
+		"status":     models.EmailStatusSent,
+		"sent_at":    sentAt,
+		"payload":    "{}",
+		"last_error": nil,
+	})
+}
+
+func (repo *EmailOutboxRepoImpl) MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	return repo.update(ctx, id, map[string]interface{}{
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	})
+}
+
+func (repo *EmailOutboxRepoImpl) MarkDead(ctx context.Context, id int64, lastError string) error {
+	return repo.update(ctx, id, map[string]interface{}{
+		"status":     models.EmailStatusDead,
+		"last_error": lastError,
+	})
+}
+
+// MarkExpired gives up on an email whose content is no longer valid, such as
+// a one-time code past its lifetime, and clears the payload.
+func (repo *EmailOutboxRepoImpl) MarkExpired(ctx context.Context, id int64) error {
+	return repo.update(ctx, id, map[string]interface{}{
+		"status":  models.EmailStatusExpired,
+		"payload": "{}",
+	})
+}
+
+// Retry puts a dead email back in the queue. It returns false when the email
+// does not exist or is not dead.
+func (repo *EmailOutboxRepoImpl) Retry(ctx context.Context, id int64, now time.Time) (bool, error) {
+	result := dbFromContext(ctx, repo.DB).
+		Table(models.EmailOutbox{}.TableName()).
+		Where("id = ? AND status = ?", id, models.EmailStatusDead).
+		Updates(map[string]interface{}{
+			"status":          models.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *EmailOutboxRepoImpl) GetById(ctx context.Context, id int64) (*models.EmailOutbox, error) {
+	var email models.EmailOutbox
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.EmailOutbox{}.TableName()).
+		Where("id = ?", id).
+		First(&email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+func (repo *EmailOutboxRepoImpl) GetList(ctx context.Context, paging *common.Paging, cond map[string]interface{}) ([]*models.EmailOutbox, error) {
+	query := dbFromContext(ctx, repo.DB).Table(models.EmailOutbox{}.TableName()).Where(cond)
+	return findPage(query, paging, emailCursorKey, parseEmailID)
+}
+
+func emailCursorKey(email *models.EmailOutbox) (time.Time, string) {
+	return email.CreatedAt, strconv.FormatInt(email.ID, 10)
+}
+
+func parseEmailID(id string) (interface{}, error) {
+	return strconv.ParseInt(id, 10, 64)
+}
+
+func (repo *EmailOutboxRepoImpl) update(ctx context.Context, id int64, values map[string]interface{}) error {
+	values["updated_at"] = time.Now()
+	return dbFromContext(ctx, repo.DB).
+		Table(models.EmailOutbox{}.TableName()).
+		Where("id = ?", id).
+		Updates(values).Error
+}
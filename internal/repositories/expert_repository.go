@@ -8,7 +8,7 @@ import (
 )
 
 type ExpertRepository interface {
-	Create(ctx context.Context, expert *models.ExpertCreate) (error)
+	Create(ctx context.Context, expert *models.Expert) (error)
 }
 
 type ExpertRepositoryImpl struct {
@@ -19,9 +19,9 @@ func NewExpertRepositoryImpl(db *gorm.DB) *ExpertRepositoryImpl{
 	return &ExpertRepositoryImpl{DB: db}
 }
 
-func(repo *ExpertRepositoryImpl) Create(ctx context.Context, expert *models.ExpertCreate) (error) {
-	if err := repo.DB.WithContext(ctx).
-		Table(models.ExpertCreate{}.TableName()).
+func(repo *ExpertRepositoryImpl) Create(ctx context.Context, expert *models.Expert) (error) {
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Expert{}.TableName()).
 		Create(expert).Error; err != nil {
 			return err
 	}
@@ -0,0 +1,440 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often expired keys are dropped from memory.
+// Reads never see them anyway.
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // zero means no expiry
+}
+
+// MemoryStoreImpl is an in-process RedisStore for local development and unit
+// tests. It
+// keeps the keys, TTLs and atomicity of RedisStoreImpl, but its data is lost
+// on restart and not shared between instances of the API.
+type MemoryStoreImpl struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStoreImpl {
+	return &MemoryStoreImpl{
+		entries:   make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// NewStore picks the store from STORE_DRIVER. The in-memory store is only
+// used when asked for, a missing REDIS_ADDR is an error.
+func NewStore() (RedisStore, error) {
+	switch config.AppConfig.StoreDriver {
+	case "memory":
+		log.Println("Warning: STORE_DRIVER is memory, using the in-memory store (single instance only)")
+		return NewMemoryStore(), nil
+	case "redis":
+		if config.AppConfig.RedisHost == "" {
+			return nil, fmt.Errorf("REDIS_ADDR is not set, set it or use STORE_DRIVER=memory for a single local instance")
+		}
+		return NewRedisStore()
+	default:
+		return nil, fmt.Errorf("unknown STORE_DRIVER %q", config.AppConfig.StoreDriver)
+	}
+}
+
+// The helpers below expect m.mu to be held.
+
+func (m *MemoryStoreImpl) get(key string) (string, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return "", false
+	}
+
+	return entry.value, true
+}
+
+// set stores the value; a ttl of zero or less keeps it forever like SET
+// without expiry.
+func (m *MemoryStoreImpl) set(key, value string, ttl time.Duration) {
+	m.sweep()
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+}
+
+func (m *MemoryStoreImpl) del(keys ...string) {
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+}
+
+// pttl follows PTTL: -2 for a missing key, -1 for a key without expiry.
+func (m *MemoryStoreImpl) pttl(key string) time.Duration {
+	if _, ok := m.get(key); !ok {
+		return -2
+	}
+
+	entry := m.entries[key]
+	if entry.expiresAt.IsZero() {
+		return -1
+	}
+	return time.Until(entry.expiresAt)
+}
+
+func (m *MemoryStoreImpl) getInt(key string) int64 {
+	value, ok := m.get(key)
+	if !ok {
+		return 0
+	}
+
+	count, _ := strconv.ParseInt(value, 10, 64)
+	return count
+}
+
+// incr behaves like INCR and keeps the expiry of an existing key.
+func (m *MemoryStoreImpl) incr(key string) int64 {
+	count := m.getInt(key) + 1
+	entry := m.entries[key]
+	entry.value = strconv.FormatInt(count, 10)
+	m.entries[key] = entry
+	return count
+}
+
+// incrWithWindow is incrWithWindowScript: the window starts with the first
+// increment.
+func (m *MemoryStoreImpl) incrWithWindow(key string, window time.Duration) int64 {
+	count := m.incr(key)
+	if count == 1 && window > 0 {
+		m.entries[key] = memoryEntry{value: "1", expiresAt: time.Now().Add(window)}
+	}
+	return count
+}
+
+func (m *MemoryStoreImpl) sweep() {
+	now := time.Now()
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, entry := range m.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+func (m *MemoryStoreImpl) StoreOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(otpKey(purpose, email), otpHash, ttl)
+	m.del(otpAttemptsKey(purpose, email))
+	return nil
+}
+
+// VerifyOTP mirrors verifyOTPScript.
+func (m *MemoryStoreImpl) VerifyOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, maxAttempts int) (OTPStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codeKey, attemptsKey := otpKey(purpose, email), otpAttemptsKey(purpose, email)
+	if m.getInt(attemptsKey) >= int64(maxAttempts) {
+		return OTPTooManyAttempts, nil
+	}
+
+	stored, ok := m.get(codeKey)
+	if !ok {
+		return OTPExpired, nil
+	}
+
+	if stored == otpHash {
+		m.del(codeKey, attemptsKey)
+		return OTPValid, nil
+	}
+
+	attempts := m.incrWithWindow(attemptsKey, m.pttl(codeKey))
+	if attempts >= int64(maxAttempts) {
+		m.del(codeKey)
+		return OTPTooManyAttempts, nil
+	}
+
+	return OTPInvalid, nil
+}
+
+// ReserveOTPSend mirrors reserveOTPSendScript.
+func (m *MemoryStoreImpl) ReserveOTPSend(
+	ctx context.Context,
+	purpose common.OTPPurpose,
+	email string,
+	cooldown, window time.Duration,
+	limit int,
+) (*OTPSendQuota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cooldownKey, countKey := otpCooldownKey(purpose, email), otpSendCountKey(purpose, email)
+	if remaining := m.pttl(cooldownKey); remaining > 0 {
+		return &OTPSendQuota{
+			RetryAfter: remaining,
+			Remaining:  limit - int(m.getInt(countKey)),
+		}, nil
+	}
+
+	if m.getInt(countKey) >= int64(limit) {
+		return &OTPSendQuota{
+			LimitReached: true,
+			RetryAfter:   m.pttl(countKey),
+		}, nil
+	}
+
+	count := m.incrWithWindow(countKey, window)
+	m.set(cooldownKey, "1", cooldown)
+
+	return &OTPSendQuota{
+		Allowed:   true,
+		Remaining: limit - int(count),
+	}, nil
+}
+
+func (m *MemoryStoreImpl) SaveRefreshToken(ctx context.Context, familyID, tokenID string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(refreshFamilyKey(familyID), tokenID, ttl)
+	return nil
+}
+
+// RotateRefreshToken mirrors rotateRefreshScript.
+func (m *MemoryStoreImpl) RotateRefreshToken(ctx context.Context, familyID, oldTokenID, newTokenID string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.get(refreshFamilyKey(familyID))
+	if !ok || current != oldTokenID {
+		return false, nil
+	}
+
+	m.set(refreshFamilyKey(familyID), newTokenID, ttl)
+	return true, nil
+}
+
+func (m *MemoryStoreImpl) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.del(refreshFamilyKey(familyID))
+	return nil
+}
+
+func (m *MemoryStoreImpl) IsRefreshFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.get(refreshFamilyKey(familyID))
+	return ok, nil
+}
+
+func (m *MemoryStoreImpl) RevokeAccessToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(revokedAccessKey(tokenID), "1", ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.get(revokedAccessKey(tokenID))
+	return ok, nil
+}
+
+func (m *MemoryStoreImpl) RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(revokedUserKey(userID), strconv.FormatInt(revokedAt.UnixMilli(), 10), ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) GetUserTokensRevokedAt(ctx context.Context, userID string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(revokedUserKey(userID))
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(millis), nil
+}
+
+func (m *MemoryStoreImpl) GetAccountState(ctx context.Context, accountID string) (*models.AccountState, error) {
+	m.mu.Lock()
+	value, ok := m.get(accountStateKey(accountID))
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	var state models.AccountState
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (m *MemoryStoreImpl) SetAccountState(ctx context.Context, accountID string, state *models.AccountState, ttl time.Duration) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(accountStateKey(accountID), string(value), ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) DeleteAccountState(ctx context.Context, accountID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.del(accountStateKey(accountID))
+	return nil
+}
+
+func (m *MemoryStoreImpl) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.incrWithWindow(loginFailureKey(key), window), nil
+}
+
+func (m *MemoryStoreImpl) LockLogin(ctx context.Context, key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(loginLockKey(key), "1", ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) GetLoginLock(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ttl := m.pttl(loginLockKey(key))
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (m *MemoryStoreImpl) ClearLoginFailures(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.del(loginFailureKey(key), loginLockKey(key))
+	return nil
+}
+
+func (m *MemoryStoreImpl) StoreMFAChallenge(ctx context.Context, challengeHash, accountID string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(mfaChallengeKey(challengeHash), accountID, ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) GetMFAChallenge(ctx context.Context, challengeHash string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accountID, _ := m.get(mfaChallengeKey(challengeHash))
+	return accountID, nil
+}
+
+func (m *MemoryStoreImpl) RecordMFAChallengeFailure(ctx context.Context, challengeHash string, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.incrWithWindow(mfaChallengeAttemptsKey(challengeHash), window), nil
+}
+
+func (m *MemoryStoreImpl) DeleteMFAChallenge(ctx context.Context, challengeHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.del(mfaChallengeKey(challengeHash), mfaChallengeAttemptsKey(challengeHash))
+	return nil
+}
+
+func (m *MemoryStoreImpl) MarkTOTPStepUsed(ctx context.Context, accountID string, step int64, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := totpStepKey(accountID, step)
+	if _, ok := m.get(key); ok {
+		return false, nil
+	}
+
+	m.set(key, "1", ttl)
+	return true, nil
+}
+
+func (m *MemoryStoreImpl) StoreResetTicket(ctx context.Context, ticketHash, email string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(resetTicketKey(ticketHash), email, ttl)
+	return nil
+}
+
+func (m *MemoryStoreImpl) ConsumeResetTicket(ctx context.Context, ticketHash string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	email, ok := m.get(resetTicketKey(ticketHash))
+	if !ok {
+		return "", nil
+	}
+
+	m.del(resetTicketKey(ticketHash))
+	return email, nil
+}
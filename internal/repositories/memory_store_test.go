@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreVerifyOTP(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	purpose, email := common.OTPPurposeVerifyEmail, "user@example.com"
+
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "hash", 3); status != OTPExpired {
+		t.Fatalf("status without a code = %v, want OTPExpired", status)
+	}
+
+	store.StoreOTP(ctx, purpose, email, "hash", time.Minute)
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "wrong", 3); status != OTPInvalid {
+		t.Fatalf("status of a wrong code = %v, want OTPInvalid", status)
+	}
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "hash", 3); status != OTPValid {
+		t.Fatalf("status of the code = %v, want OTPValid", status)
+	}
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "hash", 3); status != OTPExpired {
+		t.Fatalf("status of a used code = %v, want OTPExpired", status)
+	}
+}
+
+func TestMemoryStoreVerifyOTPTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	purpose, email := common.OTPPurposeResetPassword, "user@example.com"
+
+	store.StoreOTP(ctx, purpose, email, "hash", time.Minute)
+	store.VerifyOTP(ctx, purpose, email, "wrong", 2)
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "wrong", 2); status != OTPTooManyAttempts {
+		t.Fatalf("status of the last allowed guess = %v, want OTPTooManyAttempts", status)
+	}
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "hash", 2); status != OTPTooManyAttempts {
+		t.Fatalf("status of the right code after the limit = %v, want OTPTooManyAttempts", status)
+	}
+
+	// A new code starts the attempts again
+	store.StoreOTP(ctx, purpose, email, "new", time.Minute)
+	if status, _ := store.VerifyOTP(ctx, purpose, email, "new", 2); status != OTPValid {
+		t.Fatalf("status of a new code = %v, want OTPValid", status)
+	}
+}
+
+func TestMemoryStoreReserveOTPSend(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	purpose, email := common.OTPPurposeVerifyEmail, "user@example.com"
+
+	quota, _ := store.ReserveOTPSend(ctx, purpose, email, time.Minute, time.Hour, 2)
+	if !quota.Allowed || quota.Remaining != 1 {
+		t.Fatalf("first send = %+v, want allowed with 1 remaining", quota)
+	}
+
+	quota, _ = store.ReserveOTPSend(ctx, purpose, email, time.Minute, time.Hour, 2)
+	if quota.Allowed || quota.LimitReached || quota.RetryAfter <= 0 {
+		t.Fatalf("send within the cooldown = %+v, want refused with a retry time", quota)
+	}
+
+	// Without a cooldown only the limit of the window applies
+	store.ReserveOTPSend(ctx, purpose, "other@example.com", 0, time.Hour, 1)
+	quota, _ = store.ReserveOTPSend(ctx, purpose, "other@example.com", 0, time.Hour, 1)
+	if quota.Allowed || !quota.LimitReached {
+		t.Fatalf("send over the limit = %+v, want refused with LimitReached", quota)
+	}
+}
+
+func TestMemoryStoreRotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.SaveRefreshToken(ctx, "family", "first", time.Hour)
+	if rotated, _ := store.RotateRefreshToken(ctx, "family", "first", "second", time.Hour); !rotated {
+		t.Fatal("rotating the current token failed")
+	}
+	if rotated, _ := store.RotateRefreshToken(ctx, "family", "first", "third", time.Hour); rotated {
+		t.Fatal("a reused token was rotated")
+	}
+
+	store.RevokeRefreshFamily(ctx, "family")
+	if active, _ := store.IsRefreshFamilyActive(ctx, "family"); active {
+		t.Fatal("a revoked family is still active")
+	}
+}
+
+func TestMemoryStoreLoginLock(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for want := int64(1); want <= 3; want++ {
+		if failures, _ := store.RecordLoginFailure(ctx, "account:a", time.Hour); failures != want {
+			t.Fatalf("failures = %d, want %d", failures, want)
+		}
+	}
+
+	store.LockLogin(ctx, "account:a", time.Minute)
+	if ttl, _ := store.GetLoginLock(ctx, "account:a"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("lock = %s, want up to a minute", ttl)
+	}
+
+	store.ClearLoginFailures(ctx, "account:a")
+	if ttl, _ := store.GetLoginLock(ctx, "account:a"); ttl != 0 {
+		t.Fatalf("lock after clearing = %s, want none", ttl)
+	}
+	if failures, _ := store.RecordLoginFailure(ctx, "account:a", time.Hour); failures != 1 {
+		t.Fatalf("failures after clearing = %d, want 1", failures)
+	}
+}
+
+func TestMemoryStoreRevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	revokedAt := time.UnixMilli(time.Now().UnixMilli())
+	store.RevokeUserTokens(ctx, "user", revokedAt, time.Hour)
+
+	got, err := store.GetUserTokensRevokedAt(ctx, "user")
+	if err != nil || !got.Equal(revokedAt) {
+		t.Fatalf("revoked at = %s, %v, want %s to the millisecond", got, err, revokedAt)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.StoreMFAChallenge(ctx, "challenge", "account", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if accountID, _ := store.GetMFAChallenge(ctx, "challenge"); accountID != "" {
+		t.Fatalf("expired challenge still returns %q", accountID)
+	}
+}
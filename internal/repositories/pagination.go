@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// cursorKey reads the position of a row for the cursor of the next page.
+type cursorKey[T any] func(row *T) (createdAt time.Time, id string)
+
+// findPage loads the page of query that paging asks for, after ProcessPaging
+// and ProcessSort. Page mode counts the rows and skips the pages before with
+// OFFSET. Cursor mode seeks past the cursor on (created_at, id), reads one row
+// more than the limit to know whether another page follows and only counts
+// the rows when the client asked for the total. parseID turns the id of a
+// cursor back into a value of the id column.
+func findPage[T any](query *gorm.DB, paging *common.Paging, key cursorKey[T], parseID func(id string) (interface{}, error)) ([]*T, error) {
+	var rows []*T
+
+	if !paging.IsCursorMode() || paging.WithTotal {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return nil, err
+		}
+		paging.SetTotal(total)
+	}
+
+	if !paging.IsCursorMode() {
+		if err := query.
+			Order(paging.OrderBy("id")).
+			Offset((paging.Page - 1) * paging.Limit).
+			Limit(paging.Limit).
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return rows, nil
+	}
+
+	if paging.Cursor != "" {
+		cursor, err := common.DecodeCursor(paging.Cursor, paging.Order)
+		if err != nil {
+			return nil, err
+		}
+		id, err := parseID(cursor.ID)
+		if err != nil {
+			return nil, common.ErrInvalidCursor
+		}
+
+		operator := "<"
+		if paging.Order == "asc" {
+			operator = ">"
+		}
+		query = query.Where(fmt.Sprintf("(created_at, id) %s (?, ?)", operator), cursor.CreatedAt, id)
+	}
+
+	if err := query.
+		Order(paging.OrderBy("id")).
+		Limit(paging.Limit + 1).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	paging.HasMore = len(rows) > paging.Limit
+	if paging.HasMore {
+		rows = rows[:paging.Limit]
+		createdAt, id := key(rows[len(rows)-1])
+		paging.NextCursor = common.Cursor{CreatedAt: createdAt, ID: id, Order: paging.Order}.Encode()
+	}
+
+	return rows, nil
+}
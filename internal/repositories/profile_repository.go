@@ -10,7 +10,9 @@ import (
 type ProfileRepository interface {
 	GetProfileByID(ctx context.Context, profileID string) (*models.Profile, error)
 	Create(ctx context.Context, profile *models.Profile) (*models.Profile, error)
+	CreateBatch(ctx context.Context, profiles []*models.Profile) error
 	Update(ctx context.Context, cond map[string]interface{}, profile *models.Profile) (error)
+	Delete(ctx context.Context, profileID string) (bool, error)
 }
 
 type ProfileRepositoryImpl struct {
@@ -26,7 +28,7 @@ func NewProfileRepoImpl(db *gorm.DB) *ProfileRepositoryImpl {
 func(r *ProfileRepositoryImpl) GetProfileByID(ctx context.Context, profileID string) (*models.Profile, error){
 	var profile models.Profile
 
-	if err := r.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, r.DB).
 		Table(models.Profile{}.TableName()).
 		Where("user_id = ?", profileID).
 		First(&profile).Error; err != nil {
@@ -41,7 +43,7 @@ func(r *ProfileRepositoryImpl) GetProfileByID(ctx context.Context, profileID str
 
 func(r *ProfileRepositoryImpl) Create(ctx context.Context, profile *models.Profile) (*models.Profile, error){
 
-	if err := r.DB.WithContext(ctx).
+	if err := dbFromContext(ctx, r.DB).
 		Table(models.Profile{}.TableName()).
 		Create(profile).Error; err != nil {
 		return nil, err
@@ -50,16 +52,37 @@ func(r *ProfileRepositoryImpl) Create(ctx context.Context, profile *models.Profi
 	return profile, nil
 }
 
+// CreateBatch inserts the profiles with one statement.
+func(r *ProfileRepositoryImpl) CreateBatch(ctx context.Context, profiles []*models.Profile) error {
+	return dbFromContext(ctx, r.DB).
+		Table(models.Profile{}.TableName()).
+		Create(&profiles).Error
+}
+
 func(r *ProfileRepositoryImpl) Update(
 	ctx context.Context, 
 	cond map[string]interface{}, 
 	profile *models.Profile,
 	) (error) {
-		if err := r.DB.WithContext(ctx).
+		if err := dbFromContext(ctx, r.DB).
 		Table(models.Profile{}.TableName()).
 		Where(cond).
+		// Every column, so a false gender is written too
+		Select("*").Omit("user_id", "created_at").
 		Updates(profile).Error; err != nil {
 			return err
 		}
 		return nil
-}
\ No newline at end of file
+}
+
+// Delete reports whether there was a profile to remove.
+func(r *ProfileRepositoryImpl) Delete(ctx context.Context, profileID string) (bool, error) {
+	result := dbFromContext(ctx, r.DB).
+		Where("user_id = ?", profileID).
+		Delete(&models.Profile{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceForAccount(ctx context.Context, accountID uuid.UUID, codeHashes []string) error
+	Use(ctx context.Context, accountID uuid.UUID, codeHash string) (bool, error)
+	DeleteForAccount(ctx context.Context, accountID uuid.UUID) error
+}
+
+type RecoveryCodeRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewRecoveryCodeRepoImpl(db *gorm.DB) *RecoveryCodeRepoImpl {
+	return &RecoveryCodeRepoImpl{DB: db}
+}
+
+// ReplaceForAccount drops every previous code of the account and stores the
+// new set.
+func (repo *RecoveryCodeRepoImpl) ReplaceForAccount(ctx context.Context, accountID uuid.UUID, codeHashes []string) error {
+	return dbFromContext(ctx, repo.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(models.RecoveryCode{}.TableName()).
+			Where("account_id = ?", accountID).
+			Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]*models.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, &models.RecoveryCode{AccountID: accountID, CodeHash: hash})
+		}
+
+		return tx.Table(models.RecoveryCode{}.TableName()).Create(&codes).Error
+	})
+}
+
+// Use marks an unused code as used. It returns false when no such code is
+// left, so a code can never be used twice even by concurrent requests.
+func (repo *RecoveryCodeRepoImpl) Use(ctx context.Context, accountID uuid.UUID, codeHash string) (bool, error) {
+	result := dbFromContext(ctx, repo.DB).
+		Table(models.RecoveryCode{}.TableName()).
+		Where("account_id = ? AND code_hash = ? AND used_at IS NULL", accountID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *RecoveryCodeRepoImpl) DeleteForAccount(ctx context.Context, accountID uuid.UUID) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.RecoveryCode{}.TableName()).
+		Where("account_id = ?", accountID).
+		Delete(&models.RecoveryCode{}).Error
+}
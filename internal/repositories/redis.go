@@ -1,17 +1,65 @@
 package repositories
 
 import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
 	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
+
+// OTPStatus is the outcome of checking a one-time password.
+type OTPStatus int
+
+const (
+	OTPValid OTPStatus = iota
+	OTPInvalid
+	OTPExpired
+	OTPTooManyAttempts
+)
+
+// OTPSendQuota is the result of ReserveOTPSend. RetryAfter is set when the
+// send was refused, either by the cooldown or by the limit of the window.
+type OTPSendQuota struct {
+	Allowed      bool
+	LimitReached bool
+	RetryAfter   time.Duration
+	Remaining    int
+}
+
 type RedisStore interface {
-	StoreOTP(ctx context.Context, email, otp string) error
-	VerifyOTP(ctx context.Context, email, otp string) (bool, error)
+	StoreOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, ttl time.Duration) error
+	VerifyOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, maxAttempts int) (OTPStatus, error)
+	ReserveOTPSend(ctx context.Context, purpose common.OTPPurpose, email string, cooldown, window time.Duration, limit int) (*OTPSendQuota, error)
+	SaveRefreshToken(ctx context.Context, familyID, tokenID string, ttl time.Duration) error
+	RotateRefreshToken(ctx context.Context, familyID, oldTokenID, newTokenID string, ttl time.Duration) (bool, error)
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+	IsRefreshFamilyActive(ctx context.Context, familyID string) (bool, error)
+	RevokeAccessToken(ctx context.Context, tokenID string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time, ttl time.Duration) error
+	GetUserTokensRevokedAt(ctx context.Context, userID string) (time.Time, error)
+	GetAccountState(ctx context.Context, accountID string) (*models.AccountState, error)
+	SetAccountState(ctx context.Context, accountID string, state *models.AccountState, ttl time.Duration) error
+	DeleteAccountState(ctx context.Context, accountID string) error
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	LockLogin(ctx context.Context, key string, ttl time.Duration) error
+	GetLoginLock(ctx context.Context, key string) (time.Duration, error)
+	ClearLoginFailures(ctx context.Context, key string) error
+	StoreMFAChallenge(ctx context.Context, challengeHash, accountID string, ttl time.Duration) error
+	GetMFAChallenge(ctx context.Context, challengeHash string) (string, error)
+	RecordMFAChallengeFailure(ctx context.Context, challengeHash string, window time.Duration) (int64, error)
+	DeleteMFAChallenge(ctx context.Context, challengeHash string) error
+	MarkTOTPStepUsed(ctx context.Context, accountID string, step int64, ttl time.Duration) (bool, error)
+	StoreResetTicket(ctx context.Context, ticketHash, email string, ttl time.Duration) error
+	ConsumeResetTicket(ctx context.Context, ticketHash string) (string, error)
 }
 
 
@@ -49,22 +97,369 @@ func NewRedisStore() (*RedisStoreImpl, error) {
 	return &RedisStoreImpl{client: client}, nil
 }
 
-func (r *RedisStoreImpl) StoreOTP(ctx context.Context, email, otp string) error {
-	key := fmt.Sprintf("otp:%s", email)
-	return r.client.SetEX(ctx, key, otp, 10*time.Minute).Err()
+func otpKey(purpose common.OTPPurpose, email string) string {
+	return fmt.Sprintf("otp:%s:%s", purpose, strings.ToLower(email))
 }
 
-func (r *RedisStoreImpl) VerifyOTP(ctx context.Context, email, otp string) (bool, error) {
-	key := fmt.Sprintf("otp:%s", email)
-	storeOTP, err := r.client.Get(ctx, key).Result()
-	
-	if err == redis.Nil {
-		return false, nil
+func otpAttemptsKey(purpose common.OTPPurpose, email string) string {
+	return fmt.Sprintf("otp:attempts:%s:%s", purpose, strings.ToLower(email))
+}
+
+// StoreOTP replaces any previous code for the purpose and resets its attempt
+// counter. Only the hash of the code is stored.
+func (r *RedisStoreImpl) StoreOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, otpKey(purpose, email), otpHash, ttl)
+	pipe.Del(ctx, otpAttemptsKey(purpose, email))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// verifyOTPScript checks a code and counts wrong guesses in one step.
+// A matching code is consumed; reaching the attempt limit deletes the code and
+// keeps the counter until the code would have expired, so the lockout holds.
+var verifyOTPScript = redis.NewScript(`
+local attempts = tonumber(redis.call("GET", KEYS[2]) or "0")
+if attempts >= tonumber(ARGV[2]) then
+	return 3
+end
+local stored = redis.call("GET", KEYS[1])
+if not stored then
+	return 2
+end
+if stored == ARGV[1] then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return 0
+end
+attempts = redis.call("INCR", KEYS[2])
+if attempts == 1 then
+	redis.call("PEXPIRE", KEYS[2], redis.call("PTTL", KEYS[1]))
+end
+if attempts >= tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+	return 3
+end
+return 1
+`)
+
+func (r *RedisStoreImpl) VerifyOTP(ctx context.Context, purpose common.OTPPurpose, email, otpHash string, maxAttempts int) (OTPStatus, error) {
+	status, err := verifyOTPScript.Run(
+		ctx,
+		r.client,
+		[]string{otpKey(purpose, email), otpAttemptsKey(purpose, email)},
+		otpHash, maxAttempts,
+	).Int()
+	if err != nil {
+		return OTPInvalid, err
+	}
+
+	return OTPStatus(status), nil
+}
+
+func otpCooldownKey(purpose common.OTPPurpose, email string) string {
+	return fmt.Sprintf("otp:cooldown:%s:%s", purpose, strings.ToLower(email))
+}
+
+func otpSendCountKey(purpose common.OTPPurpose, email string) string {
+	return fmt.Sprintf("otp:sends:%s:%s", purpose, strings.ToLower(email))
+}
+
+// reserveOTPSendScript returns {status, retry after in ms, remaining sends}
+// where status is 0 when allowed, 1 during the cooldown and 2 when the
+// window limit is reached.
+var reserveOTPSendScript = redis.NewScript(`
+local cooldown = redis.call("PTTL", KEYS[1])
+if cooldown > 0 then
+	local count = tonumber(redis.call("GET", KEYS[2]) or "0")
+	return {1, cooldown, tonumber(ARGV[3]) - count}
+end
+local count = tonumber(redis.call("GET", KEYS[2]) or "0")
+if count >= tonumber(ARGV[3]) then
+	return {2, redis.call("PTTL", KEYS[2]), 0}
+end
+count = redis.call("INCR", KEYS[2])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+redis.call("SET", KEYS[1], 1, "PX", ARGV[1])
+return {0, 0, tonumber(ARGV[3]) - count}
+`)
+
+// ReserveOTPSend counts one send of a code against the cooldown and the
+// limit per window, refusing it when either is exhausted.
+func (r *RedisStoreImpl) ReserveOTPSend(
+	ctx context.Context,
+	purpose common.OTPPurpose,
+	email string,
+	cooldown, window time.Duration,
+	limit int,
+) (*OTPSendQuota, error) {
+	result, err := reserveOTPSendScript.Run(
+		ctx,
+		r.client,
+		[]string{otpCooldownKey(purpose, email), otpSendCountKey(purpose, email)},
+		cooldown.Milliseconds(), window.Milliseconds(), limit,
+	).Int64Slice()
+	if err != nil {
+		return nil, err
 	}
 
+	return &OTPSendQuota{
+		Allowed:      result[0] == 0,
+		LimitReached: result[0] == 2,
+		RetryAfter:   time.Duration(result[1]) * time.Millisecond,
+		Remaining:    int(result[2]),
+	}, nil
+}
+
+// rotateRefreshScript replaces the current refresh token id of a family only
+// when the presented id is still the current one, so two concurrent refreshes
+// with the same token cannot both succeed.
+var rotateRefreshScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
+func refreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh:family:%s", familyID)
+}
+
+// SaveRefreshToken starts a refresh token family with its first token id.
+func (r *RedisStoreImpl) SaveRefreshToken(ctx context.Context, familyID, tokenID string, ttl time.Duration) error {
+	return r.client.Set(ctx, refreshFamilyKey(familyID), tokenID, ttl).Err()
+}
+
+// RotateRefreshToken moves the family to newTokenID. It returns false when
+// oldTokenID is not the current token of the family, which means the token was
+// already used or the family has been revoked.
+func (r *RedisStoreImpl) RotateRefreshToken(ctx context.Context, familyID, oldTokenID, newTokenID string, ttl time.Duration) (bool, error) {
+	rotated, err := rotateRefreshScript.Run(
+		ctx,
+		r.client,
+		[]string{refreshFamilyKey(familyID)},
+		oldTokenID, newTokenID, ttl.Milliseconds(),
+	).Int()
 	if err != nil {
 		return false, err
 	}
 
-	return storeOTP == otp, nil
+	return rotated == 1, nil
+}
+
+func (r *RedisStoreImpl) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	return r.client.Del(ctx, refreshFamilyKey(familyID)).Err()
+}
+
+// IsRefreshFamilyActive reports whether the family still has a current
+// refresh token, i.e. the session was neither logged out nor revoked.
+func (r *RedisStoreImpl) IsRefreshFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	count, err := r.client.Exists(ctx, refreshFamilyKey(familyID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func revokedAccessKey(tokenID string) string {
+	return fmt.Sprintf("revoked:access:%s", tokenID)
+}
+
+func revokedUserKey(userID string) string {
+	return fmt.Sprintf("revoked:user:%s", userID)
+}
+
+// RevokeAccessToken puts a single access token on the denylist until it would
+// have expired anyway.
+func (r *RedisStoreImpl) RevokeAccessToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return r.client.Set(ctx, revokedAccessKey(tokenID), 1, ttl).Err()
+}
+
+func (r *RedisStoreImpl) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := r.client.Exists(ctx, revokedAccessKey(tokenID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeUserTokens records that every token of the user issued before
+// revokedAt is no longer valid.
+func (r *RedisStoreImpl) RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time, ttl time.Duration) error {
+	return r.client.Set(ctx, revokedUserKey(userID), revokedAt.UnixMilli(), ttl).Err()
+}
+
+// GetUserTokensRevokedAt returns the zero time when the user never signed out
+// everywhere (or the mark has outlived every token it applied to).
+func (r *RedisStoreImpl) GetUserTokensRevokedAt(ctx context.Context, userID string) (time.Time, error) {
+	value, err := r.client.Get(ctx, revokedUserKey(userID)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(millis), nil
+}
+
+func accountStateKey(accountID string) string {
+	return fmt.Sprintf("account:state:%s", accountID)
+}
+
+// GetAccountState returns nil when the state is not cached.
+func (r *RedisStoreImpl) GetAccountState(ctx context.Context, accountID string) (*models.AccountState, error) {
+	value, err := r.client.Get(ctx, accountStateKey(accountID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var state models.AccountState
+	if err := json.Unmarshal(value, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *RedisStoreImpl) SetAccountState(ctx context.Context, accountID string, state *models.AccountState, ttl time.Duration) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, accountStateKey(accountID), value, ttl).Err()
+}
+
+func (r *RedisStoreImpl) DeleteAccountState(ctx context.Context, accountID string) error {
+	return r.client.Del(ctx, accountStateKey(accountID)).Err()
+}
+
+func resetTicketKey(ticketHash string) string {
+	return fmt.Sprintf("pwreset:%s", ticketHash)
+}
+
+func (r *RedisStoreImpl) StoreResetTicket(ctx context.Context, ticketHash, email string, ttl time.Duration) error {
+	return r.client.Set(ctx, resetTicketKey(ticketHash), email, ttl).Err()
+}
+
+// ConsumeResetTicket returns the email the ticket was issued for and deletes
+// it in the same step. It returns an empty email for unknown tickets.
+func (r *RedisStoreImpl) ConsumeResetTicket(ctx context.Context, ticketHash string) (string, error) {
+	email, err := r.client.GetDel(ctx, resetTicketKey(ticketHash)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return email, nil
+}
+
+func loginFailureKey(key string) string {
+	return fmt.Sprintf("login:fail:%s", key)
+}
+
+func loginLockKey(key string) string {
+	return fmt.Sprintf("login:lock:%s", key)
+}
+
+var incrWithWindowScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RecordLoginFailure counts a failed login for the key (an account or an IP)
+// and returns the number of failures within the window.
+func (r *RedisStoreImpl) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrWithWindowScript.Run(ctx, r.client, []string{loginFailureKey(key)}, window.Milliseconds()).Int64()
+}
+
+func (r *RedisStoreImpl) LockLogin(ctx context.Context, key string, ttl time.Duration) error {
+	return r.client.Set(ctx, loginLockKey(key), 1, ttl).Err()
+}
+
+// GetLoginLock returns how long logins for the key stay locked, zero if they
+// are not.
+func (r *RedisStoreImpl) GetLoginLock(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, loginLockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (r *RedisStoreImpl) ClearLoginFailures(ctx context.Context, key string) error {
+	return r.client.Del(ctx, loginFailureKey(key), loginLockKey(key)).Err()
+}
+
+func mfaChallengeKey(challengeHash string) string {
+	return fmt.Sprintf("mfa:challenge:%s", challengeHash)
+}
+
+func mfaChallengeAttemptsKey(challengeHash string) string {
+	return fmt.Sprintf("mfa:challenge:attempts:%s", challengeHash)
+}
+
+func (r *RedisStoreImpl) StoreMFAChallenge(ctx context.Context, challengeHash, accountID string, ttl time.Duration) error {
+	return r.client.Set(ctx, mfaChallengeKey(challengeHash), accountID, ttl).Err()
+}
+
+// GetMFAChallenge returns the account the challenge was issued for, or an
+// empty string when it is unknown or expired.
+func (r *RedisStoreImpl) GetMFAChallenge(ctx context.Context, challengeHash string) (string, error) {
+	accountID, err := r.client.Get(ctx, mfaChallengeKey(challengeHash)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return accountID, nil
+}
+
+func (r *RedisStoreImpl) RecordMFAChallengeFailure(ctx context.Context, challengeHash string, window time.Duration) (int64, error) {
+	return incrWithWindowScript.Run(ctx, r.client, []string{mfaChallengeAttemptsKey(challengeHash)}, window.Milliseconds()).Int64()
+}
+
+func (r *RedisStoreImpl) DeleteMFAChallenge(ctx context.Context, challengeHash string) error {
+	return r.client.Del(ctx, mfaChallengeKey(challengeHash), mfaChallengeAttemptsKey(challengeHash)).Err()
+}
+
+func totpStepKey(accountID string, step int64) string {
+	return fmt.Sprintf("mfa:totp:used:%s:%d", accountID, step)
+}
+
+// MarkTOTPStepUsed returns false when a code of that time step was already
+// accepted for the account, which stops a code from being replayed.
+func (r *RedisStoreImpl) MarkTOTPStepUsed(ctx context.Context, accountID string, step int64, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, totpStepKey(accountID, step), 1, ttl).Result()
 }
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	GetListRoles(ctx context.Context) ([]*models.Role, error)
+	GetRole(ctx context.Context, name string) (*models.Role, error)
+	GetListPermissions(ctx context.Context) ([]*models.Permission, error)
+	GetListRolePermissions(ctx context.Context) ([]*models.RolePermission, error)
+	ReplaceRolePermissions(ctx context.Context, role string, permissions []string) error
+}
+
+type RoleRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepoImpl(db *gorm.DB) *RoleRepoImpl {
+	return &RoleRepoImpl{DB: db}
+}
+
+func (repo *RoleRepoImpl) GetListRoles(ctx context.Context) ([]*models.Role, error) {
+	var roles []*models.Role
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Role{}.TableName()).
+		Order("name").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (repo *RoleRepoImpl) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Role{}.TableName()).
+		Where("name = ?", name).
+		First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (repo *RoleRepoImpl) GetListPermissions(ctx context.Context) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Permission{}.TableName()).
+		Order("name").
+		Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (repo *RoleRepoImpl) GetListRolePermissions(ctx context.Context) ([]*models.RolePermission, error) {
+	var rolePermissions []*models.RolePermission
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.RolePermission{}.TableName()).
+		Order("role, permission").
+		Find(&rolePermissions).Error; err != nil {
+		return nil, err
+	}
+
+	return rolePermissions, nil
+}
+
+// ReplaceRolePermissions makes permissions the whole grant of role. It should
+// run in a transaction so the role is never left without its grants.
+func (repo *RoleRepoImpl) ReplaceRolePermissions(ctx context.Context, role string, permissions []string) error {
+	db := dbFromContext(ctx, repo.DB)
+
+	if err := db.
+		Where("role = ?", role).
+		Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rolePermissions := make([]*models.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		rolePermissions = append(rolePermissions, &models.RolePermission{Role: role, Permission: permission})
+	}
+
+	return db.
+		Table(models.RolePermission{}.TableName()).
+		Omit("created_at").
+		Create(&rolePermissions).Error
+}
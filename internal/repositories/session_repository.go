@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetById(ctx context.Context, id string) (*models.Session, error)
+	GetActiveListByAccountId(ctx context.Context, accountID string, now time.Time) ([]*models.Session, error)
+	Touch(ctx context.Context, id, ipAddress string, lastUsedAt, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	RevokeAllByAccountId(ctx context.Context, accountID string, revokedAt time.Time) error
+}
+
+type SessionRepoImpl struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepoImpl(db *gorm.DB) *SessionRepoImpl {
+	return &SessionRepoImpl{DB: db}
+}
+
+func (repo *SessionRepoImpl) Create(ctx context.Context, session *models.Session) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Create(session).Error
+}
+
+func (repo *SessionRepoImpl) GetById(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Where("id = ?", id).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetActiveListByAccountId returns the sessions that are neither revoked nor
+// expired, most recently used first.
+func (repo *SessionRepoImpl) GetActiveListByAccountId(ctx context.Context, accountID string, now time.Time) ([]*models.Session, error) {
+	var sessions []*models.Session
+
+	if err := dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Where("account_id = ? AND revoked_at IS NULL AND expires_at > ?", accountID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch records a refresh of the session.
+func (repo *SessionRepoImpl) Touch(ctx context.Context, id, ipAddress string, lastUsedAt, expiresAt time.Time) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"last_used_at": lastUsedAt,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+func (repo *SessionRepoImpl) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (repo *SessionRepoImpl) RevokeAllByAccountId(ctx context.Context, accountID string, revokedAt time.Time) error {
+	return dbFromContext(ctx, repo.DB).
+		Table(models.Session{}.TableName()).
+		Where("account_id = ? AND revoked_at IS NULL", accountID).
+		Update("revoked_at", revokedAt).Error
+}
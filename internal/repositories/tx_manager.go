@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// TxManager runs several repository calls as one unit of work. The
+// transaction travels in the context, so every repository called with the
+// context given to fn uses it, and a nested WithinTransaction joins it
+// instead of starting a new one.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type TxManagerImpl struct {
+	DB *gorm.DB
+}
+
+func NewTxManagerImpl(db *gorm.DB) *TxManagerImpl {
+	return &TxManagerImpl{DB: db}
+}
+
+// WithinTransaction commits when fn returns nil and rolls back otherwise,
+// including on panic.
+func (m *TxManagerImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// dbFromContext returns the transaction of ctx if there is one, db otherwise.
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
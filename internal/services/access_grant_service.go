@@ -0,0 +1,88 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGranteeNotExpert    = common.NewValidation("GRANTEE_NOT_EXPERT", "chỉ có thể cấp quyền truy cập cho tài khoản chuyên gia")
+	ErrAccessGrantNotFound = common.NewNotFound("ACCESS_GRANT_NOT_FOUND", "không tìm thấy quyền truy cập")
+)
+
+// AccessGrantService lets an account choose the experts who may read its
+// profile and health records.
+type AccessGrantService interface {
+	ListGrants(ctx context.Context, ownerID string) ([]*models.AccessGrant, error)
+	GrantAccess(ctx context.Context, ownerID string, request *dto.GrantAccessRequest) error
+	RevokeAccess(ctx context.Context, ownerID, expertAccountID string) error
+}
+
+type AccessGrantServiceImpl struct {
+	accessGrantRepository repositories.AccessGrantRepository
+	accountRepository     repositories.AccountRepository
+}
+
+func NewAccessGrantServiceImpl(accessGrantRepo repositories.AccessGrantRepository, accountRepo repositories.AccountRepository) *AccessGrantServiceImpl {
+	return &AccessGrantServiceImpl{
+		accessGrantRepository: accessGrantRepo,
+		accountRepository:     accountRepo,
+	}
+}
+
+func (s *AccessGrantServiceImpl) ListGrants(ctx context.Context, ownerID string) ([]*models.AccessGrant, error) {
+	grants, err := s.accessGrantRepository.GetListByOwnerId(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách quyền truy cập: %w", err)
+	}
+
+	return grants, nil
+}
+
+// GrantAccess is idempotent, granting the same expert twice keeps the first
+// grant.
+func (s *AccessGrantServiceImpl) GrantAccess(ctx context.Context, ownerID string, request *dto.GrantAccessRequest) error {
+	ownerUUID, err := uuid.Parse(ownerID)
+	if err != nil {
+		return ErrAccountNotFound
+	}
+
+	expert, err := s.accountRepository.GetAccountById(ctx, request.ExpertAccountID.String())
+	if err != nil {
+		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+	if expert == nil || expert.Role != models.RoleExpert {
+		return ErrGranteeNotExpert
+	}
+
+	if err := s.accessGrantRepository.Create(ctx, &models.AccessGrant{
+		OwnerID:   ownerUUID,
+		GranteeID: expert.ID,
+	}); err != nil {
+		return fmt.Errorf("lỗi khi cấp quyền truy cập: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AccessGrantServiceImpl) RevokeAccess(ctx context.Context, ownerID, expertAccountID string) error {
+	if _, err := uuid.Parse(expertAccountID); err != nil {
+		return ErrAccessGrantNotFound
+	}
+
+	deleted, err := s.accessGrantRepository.Delete(ctx, ownerID, expertAccountID)
+	if err != nil {
+		return fmt.Errorf("lỗi khi thu hồi quyền truy cập: %w", err)
+	}
+	if !deleted {
+		return ErrAccessGrantNotFound
+	}
+
+	return nil
+}
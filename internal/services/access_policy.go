@@ -0,0 +1,110 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AccessAction is what an actor wants to do with a resource owned by an
+// account.
+type AccessAction int
+
+const (
+	AccessRead AccessAction = iota
+	AccessUpdate
+)
+
+var (
+	ErrResourceAccessDenied = common.NewForbidden("RESOURCE_ACCESS_DENIED", "bạn không có quyền truy cập tài nguyên của tài khoản này")
+	ErrRoleOutranksActor    = common.NewForbidden("ROLE_OUTRANKS_ACTOR", "không thể thao tác trên tài khoản có vai trò cao hơn vai trò của bạn")
+)
+
+// Actor is the authenticated account behind a request.
+type Actor struct {
+	UserID string
+	Role   string
+}
+
+// OwnedResource is a kind of resource that belongs to one account, such as
+// the profile or a health record. The permissions let a role act on the
+// resources of every account.
+type OwnedResource struct {
+	ReadAnyPermission   string
+	UpdateAnyPermission string
+}
+
+var ProfileResource = OwnedResource{
+	ReadAnyPermission:   models.PermissionProfileReadAny,
+	UpdateAnyPermission: models.PermissionProfileUpdateAny,
+}
+
+// AccessPolicy decides who may act on the resources of an account: the owner,
+// a role granting the ":any" permission of the resource, or, for reading, an
+// expert the owner granted access to.
+type AccessPolicy interface {
+	Authorize(ctx context.Context, actor Actor, resource OwnedResource, ownerID string, action AccessAction) error
+}
+
+type AccessPolicyImpl struct {
+	authorizationService  AuthorizationService
+	accessGrantRepository repositories.AccessGrantRepository
+}
+
+func NewAccessPolicyImpl(authorizationService AuthorizationService, accessGrantRepo repositories.AccessGrantRepository) *AccessPolicyImpl {
+	return &AccessPolicyImpl{
+		authorizationService:  authorizationService,
+		accessGrantRepository: accessGrantRepo,
+	}
+}
+
+func (p *AccessPolicyImpl) Authorize(ctx context.Context, actor Actor, resource OwnedResource, ownerID string, action AccessAction) error {
+	if actor.UserID == "" {
+		return ErrResourceAccessDenied
+	}
+	if actor.UserID == ownerID {
+		return nil
+	}
+
+	permission := resource.ReadAnyPermission
+	if action == AccessUpdate {
+		permission = resource.UpdateAnyPermission
+	}
+	allowed, err := p.authorizationService.HasPermission(ctx, actor.Role, permission)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		return nil
+	}
+
+	// A grant only lets the expert read, the owner keeps the changes
+	if action == AccessRead && actor.Role == models.RoleExpert {
+		if _, err := uuid.Parse(ownerID); err != nil {
+			return ErrResourceAccessDenied
+		}
+		granted, err := p.accessGrantRepository.Exists(ctx, ownerID, actor.UserID)
+		if err != nil {
+			return fmt.Errorf("lỗi khi kiểm tra quyền truy cập: %w", err)
+		}
+		if granted {
+			return nil
+		}
+	}
+
+	return ErrResourceAccessDenied
+}
+
+// checkRoleRank refuses the admin actions of actor on an account whose role
+// outranks its own, so a permission such as user:lock cannot be used against
+// the admins.
+func checkRoleRank(actor Actor, role string) error {
+	if models.RoleOutranks(role, actor.Role) {
+		return ErrRoleOutranksActor
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"fmt"
+	"time"
+)
+
+// accountStateCacheTTL bounds how long a lock can take to reach requests
+// carrying an already-issued token when the cache could not be invalidated.
+const accountStateCacheTTL = 30 * time.Second
+
+var (
+	ErrAccountLocked      = common.NewForbidden("ACCOUNT_LOCKED", "tài khoản đã bị khóa")
+	ErrAccountNotVerified = common.NewForbidden("ACCOUNT_NOT_VERIFIED", "tài khoản chưa được xác thực")
+)
+
+// accountLockedError tells the reason and the end of a lock, both in the
+// message and in the details of the response.
+func accountLockedError(reason string, lockedUntil *time.Time) *common.AppError {
+	message := ErrAccountLocked.Message
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	if lockedUntil != nil {
+		message = fmt.Sprintf("%s (đến %s)", message, lockedUntil.Format(time.RFC3339))
+	}
+
+	return ErrAccountLocked.WithMessage(message).WithDetails(map[string]interface{}{
+		"reason":       reason,
+		"locked_until": lockedUntil,
+	})
+}
+
+// checkAccountState returns the error that keeps an account out of the API,
+// or nil when the account may be used.
+func checkAccountState(state *models.AccountState, now time.Time) error {
+	if !state.Verified {
+		return ErrAccountNotVerified
+	}
+
+	if state.IsLocked(now) {
+		return accountLockedError(state.LockReason, state.LockedUntil)
+	}
+
+	return nil
+}
@@ -2,48 +2,94 @@ package services
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
-	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidRefreshToken = common.NewUnauthorized("INVALID_REFRESH_TOKEN", "refresh token không hợp lệ hoặc đã hết hạn")
+	ErrRefreshTokenReused  = common.NewUnauthorized("REFRESH_TOKEN_REUSED", "refresh token đã được sử dụng, phiên đăng nhập đã bị thu hồi")
+	ErrInvalidAccessToken  = common.NewUnauthorized("INVALID_ACCESS_TOKEN", "access token không hợp lệ hoặc đã hết hạn")
+	ErrTokenRevoked        = common.NewUnauthorized("TOKEN_REVOKED", "token đã bị thu hồi")
+	ErrInvalidResetTicket  = common.NewValidation("INVALID_RESET_TICKET", "phiên đặt lại mật khẩu không hợp lệ hoặc đã hết hạn")
+	ErrAccountNotFound     = common.NewNotFound("ACCOUNT_NOT_FOUND", "tài khoản không tồn tại")
+	ErrAccountExists       = common.NewConflict("ACCOUNT_EXISTS", "tài khoản đã tồn tại")
+	ErrWrongOldPassword    = common.NewValidation("WRONG_OLD_PASSWORD", "mật khẩu cũ không chính xác")
+)
+
+// ResetTicketTTL is how long the ticket issued after a verified reset-password
+// OTP can be used to set the new password.
+const ResetTicketTTL = 10 * time.Minute
+
 type AuthService interface {
-	RegisterAccount(ctx context.Context, account *models.Account) error
-	VerifyOTP(ctx context.Context, toEmail, otp string, isVerified bool) (bool, error)
-	Login(ctx context.Context, loginRequest *common.RequestAuth) (*models.Account, string, string, error)
+	RegisterAccount(ctx context.Context, registerRequest *dto.RegisterAccountRequest) error
+	VerifyOTP(ctx context.Context, purpose common.OTPPurpose, toEmail, otp string) (bool, error)
+	Login(ctx context.Context, loginRequest *common.RequestAuth, client ClientInfo) (*LoginResult, error)
+	CompleteMFALogin(ctx context.Context, verifyRequest *common.RequestMFAVerify, client ClientInfo) (*LoginResult, error)
 	ForgotPassowrd(ctx context.Context, email string) (bool, error)
-	ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestAuth) error
+	ResendOTP(ctx context.Context, purpose common.OTPPurpose, email string) (*OTPSendStatus, error)
+	VerifyResetPasswordOTP(ctx context.Context, email, otp string) (string, error)
+	ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestResetPassword) error
 	ChangePassword(ctx context.Context, id string, changePasswordRequest *common.RequestChangePassword) error
-	RefreshToken(ctx context.Context, requestRefreshToken *common.RequestRefreshToken) (string, error)
+	ChangeLocale(ctx context.Context, id, locale string) error
+	RefreshToken(ctx context.Context, requestRefreshToken *common.RequestRefreshToken, client ClientInfo) (*utils.TokenPair, error)
+	AuthenticateAccessToken(ctx context.Context, accessToken string) (*utils.TokenClaims, error)
+	Logout(ctx context.Context, claims *utils.TokenClaims) error
+	LogoutAll(ctx context.Context, userID string) error
+}
+
+// LoginResult holds either the issued tokens or, when a second factor is
+// needed, the MFA challenge to complete at /auth/mfa/verify.
+type LoginResult struct {
+	Account       *models.Account
+	Tokens        *utils.TokenPair
+	MFAChallenge  *MFAChallenge
+	RecoveryCodes []string
 }
 
 type AuthServiceImpl struct {
 	accountRepository repositories.AccountRepository
+	sessionRepository repositories.SessionRepository
 	redisStore        repositories.RedisStore
-	emailConfig       EmailConfig
-	tokenService      utils.TokenService
+	mfaService        MFAService
+	mailService       MailService
+	sendOTPService    SendOTPService
+	tokenService      *utils.TokenService
+	txManager         repositories.TxManager
 }
 
-func NewAuthServiceImpl(accountRepo repositories.AccountRepository, redis repositories.RedisStore) *AuthServiceImpl {
+func NewAuthServiceImpl(
+	accountRepo repositories.AccountRepository,
+	sessionRepo repositories.SessionRepository,
+	redis repositories.RedisStore,
+	mfaService MFAService,
+	mailService MailService,
+	tokenService *utils.TokenService,
+	txManager repositories.TxManager,
+) *AuthServiceImpl {
 	return &AuthServiceImpl{
 		accountRepository: accountRepo,
+		sessionRepository: sessionRepo,
 		redisStore:        redis,
-		emailConfig: EmailConfig{
-			SMTPHost:    config.AppConfig.SMTPHost,
-			SMTPPort:    config.AppConfig.SMTPPort,
-			SenderEmail: config.AppConfig.SenderEmail,
-			SenderPass:  config.AppConfig.SenderPass,
-		},
-		tokenService: *utils.NewTokenService(config.AppConfig.SECRET_KEY),
+		mfaService:        mfaService,
+		mailService:       mailService,
+		sendOTPService:    NewSendOTPServiceImpl(mailService, redis),
+		tokenService: tokenService,
+		txManager:         txManager,
 	}
 }
 
-func (s *AuthServiceImpl) RegisterAccount(ctx context.Context, account *models.Account) error {
+func (s *AuthServiceImpl) RegisterAccount(ctx context.Context, registerRequest *dto.RegisterAccountRequest) error {
+	account := registerRequest.ToAccount()
+
 	// Check if the account already exists
 	existsAccount, err := s.accountRepository.GetByEmail(ctx, account.Email)
 	if err != nil {
@@ -51,7 +97,7 @@ func (s *AuthServiceImpl) RegisterAccount(ctx context.Context, account *models.A
 	}
 
 	if existsAccount != nil {
-		return fmt.Errorf("tài khoản đã tồn tại")
+		return ErrAccountExists
 	}
 
 	// Hash the password before storing
@@ -63,70 +109,183 @@ func (s *AuthServiceImpl) RegisterAccount(ctx context.Context, account *models.A
 	// Set the hashed password back to the account
 	account.Password = hashedPassword
 
-	// Create the account in the database
-	if err := s.accountRepository.Create(ctx, account); err != nil {
-		return fmt.Errorf("lỗi khi tạo tài khoản: %w", err)
-	}
+	// The account and its verification email are committed together, the
+	// email itself is delivered later by the outbox worker
+	var pending *PendingOTP
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Create(ctx, account); err != nil {
+			return fmt.Errorf("lỗi khi tạo tài khoản: %w", err)
+		}
 
-	// Generate OTP
-	sendOTPService := NewSendOTPServiceImpl(s.emailConfig, s.redisStore)
-	if err := sendOTPService.SendOTPAndStore(ctx, account.Email); err != nil {
+		pending, err = s.sendOTPService.QueueOTP(ctx, common.OTPPurposeVerifyEmail, account.Email, mailer.ParseLocale(account.Locale))
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	// Redis is only written once the account is committed, so a failed
+	// registration leaves no code and no cooldown behind
+	return s.sendOTPService.StorePendingOTP(ctx, pending)
 }
 
-func (s *AuthServiceImpl) VerifyOTP(ctx context.Context, toEmail, otp string, isVerified bool) (bool, error) {
+func (s *AuthServiceImpl) VerifyOTP(ctx context.Context, purpose common.OTPPurpose, toEmail, otp string) (bool, error) {
 	// Verify OTP
-	sendOTPService := NewSendOTPServiceImpl(s.emailConfig, s.redisStore)
-	result, err := sendOTPService.VerifyOTPInRedis(ctx, toEmail, otp)
+	result, err := s.sendOTPService.VerifyOTPInRedis(ctx, purpose, toEmail, otp)
 	if err != nil {
 		return result, err
 	}
 
-	// Update account status if OTP is valid and not already verified
-	if !isVerified {
-		isVerified = true
-		if err := s.accountRepository.Update(
-			ctx,
-			map[string]interface{}{"email": toEmail},
-			map[string]interface{}{"is_verified": isVerified}); err != nil {
-			return false, fmt.Errorf("lỗi khi cập nhật trạng thái tài khoản: %w", err)
+	// A verify-email code marks the account as verified and greets it
+	if purpose == common.OTPPurposeVerifyEmail {
+		if err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			account, err := s.accountRepository.GetByEmail(ctx, toEmail)
+			if err != nil {
+				return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+			}
+			if account == nil {
+				return nil
+			}
+
+			if err := s.accountRepository.Update(
+				ctx,
+				map[string]interface{}{"id": account.ID},
+				map[string]interface{}{"is_verified": true}); err != nil {
+				return fmt.Errorf("lỗi khi cập nhật trạng thái tài khoản: %w", err)
+			}
+			account.IsVerified = true
+
+			return s.mailService.SendWelcome(ctx, account)
+		}); err != nil {
+			return false, err
 		}
 	}
 
 	return result, nil
 }
 
-func (s *AuthServiceImpl) Login(ctx context.Context, loginRequest *common.RequestAuth) (*models.Account, string, string, error) {
+// Login answers ErrInvalidCredentials for both an unknown email and a wrong
+// password. Failures are counted per account and per client IP, and logins
+// are locked for a growing time once too many of them pile up.
+func (s *AuthServiceImpl) Login(ctx context.Context, loginRequest *common.RequestAuth, client ClientInfo) (*LoginResult, error) {
+	if err := checkLoginLock(ctx, s.redisStore, loginRequest.Email, client.IP); err != nil {
+		return nil, err
+	}
+
 	account, err := s.accountRepository.GetByEmail(ctx, loginRequest.Email)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 
+	passwordMatches := false
 	if account == nil {
-		return nil, "", "", fmt.Errorf("tài khoản không tồn tại")
+		compareDummyPassword(loginRequest.Password)
+	} else {
+		passwordMatches = utils.ComparePasswordHash(account.Password, loginRequest.Password)
+	}
+
+	if !passwordMatches {
+		if err := recordLoginFailure(ctx, s.redisStore, loginRequest.Email, client.IP); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.redisStore.ClearLoginFailures(ctx, loginAccountKey(loginRequest.Email)); err != nil {
+		return nil, fmt.Errorf("lỗi khi xóa bộ đếm đăng nhập: %w", err)
+	}
+
+	now := time.Now()
+	if err := checkAccountState(models.NewAccountState(account, now), now); err != nil {
+		return nil, err
+	}
+
+	// Accounts with 2FA, or forced into it by policy, get a challenge instead
+	if account.TOTPEnabled || s.mfaService.IsRequired(account) {
+		challenge, err := s.mfaService.CreateChallenge(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Account: account, MFAChallenge: challenge}, nil
+	}
+
+	tokens, err := s.issueTokens(ctx, account, client)
+	if err != nil {
+		return nil, err
 	}
 
-	if !account.IsVerified {
-		return nil, "", "", fmt.Errorf("tài khoản chưa được xác thực")
+	return &LoginResult{Account: account, Tokens: tokens}, nil
+}
+
+// CompleteMFALogin issues the tokens of a login once its MFA challenge is
+// answered.
+func (s *AuthServiceImpl) CompleteMFALogin(ctx context.Context, verifyRequest *common.RequestMFAVerify, client ClientInfo) (*LoginResult, error) {
+	account, recoveryCodes, err := s.mfaService.VerifyChallenge(ctx, verifyRequest)
+	if err != nil {
+		return nil, err
 	}
 
-	if !utils.ComparePasswordHash(account.Password, loginRequest.Password) {
-		return nil, "", "", fmt.Errorf("mật khẩu không chính xác")
+	// The account may have been locked while the challenge was pending
+	now := time.Now()
+	if err := checkAccountState(models.NewAccountState(account, now), now); err != nil {
+		return nil, err
 	}
 
-	// Generate access and refresh tokens
-	accessToken, refreshToken, err := s.tokenService.GenerateTokens(*account)
+	tokens, err := s.issueTokens(ctx, account, client)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("lỗi khi tạo token: %w", err)
+		return nil, err
 	}
 
-	return account, accessToken, refreshToken, nil
+	return &LoginResult{Account: account, Tokens: tokens, RecoveryCodes: recoveryCodes}, nil
 }
 
+// issueTokens starts a new refresh token family for a completed login and
+// records it as a session of the device.
+func (s *AuthServiceImpl) issueTokens(ctx context.Context, account *models.Account, client ClientInfo) (*utils.TokenPair, error) {
+	tokens, err := s.tokenService.GenerateTokens(*account, "")
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo token: %w", err)
+	}
+
+	sessionID, err := uuid.Parse(tokens.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo phiên đăng nhập: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.sessionRepository.Create(ctx, &models.Session{
+		ID:         sessionID,
+		AccountID:  account.ID,
+		DeviceName: utils.DeviceNameFromUserAgent(client.UserAgent),
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  tokens.RefreshExpiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu phiên đăng nhập: %w", err)
+	}
+
+	if err := s.redisStore.SaveRefreshToken(
+		ctx,
+		tokens.FamilyID,
+		tokens.RefreshID,
+		time.Until(tokens.RefreshExpiresAt),
+	); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu refresh token: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// ForgotPassowrd sends a reset-password code when the email has an account.
+// The cooldown and the daily limit are counted for every email and the answer
+// is the same either way, so it does not tell which emails have an account.
 func (s *AuthServiceImpl) ForgotPassowrd(ctx context.Context, email string) (bool, error) {
+	if _, err := s.sendOTPService.ReserveOTPSend(ctx, common.OTPPurposeResetPassword, email); err != nil {
+		return false, err
+	}
+
 	// Check if the account exists
 	account, err := s.accountRepository.GetByEmail(ctx, email)
 
@@ -135,45 +294,120 @@ func (s *AuthServiceImpl) ForgotPassowrd(ctx context.Context, email string) (boo
 	}
 
 	if account == nil {
-		return false, fmt.Errorf("tài khoản không tồn tại")
+		return true, nil
 	}
 
 	// Generate OTP and send it to the email
-	sendOTPService := NewSendOTPServiceImpl(s.emailConfig, s.redisStore)
-	if err := sendOTPService.SendOTPAndStore(ctx, email); err != nil {
+	if err := s.sendOTPService.IssueOTP(ctx, common.OTPPurposeResetPassword, email, mailer.ParseLocale(account.Locale)); err != nil {
 		return false, fmt.Errorf("lỗi khi gửi OTP: %w", err)
 	}
 
 	return true, nil
 }
 
-func (s *AuthServiceImpl) ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestAuth) error {
+// ResendOTP sends a new code for a flow the email is actually in: an
+// unverified account for verify-email, an existing account for reset-password.
+// The cooldown and the daily limit are counted for every email and the answer
+// is the same whether a code was sent or not, so it does not tell which
+// emails have an account or whether it is verified.
+func (s *AuthServiceImpl) ResendOTP(ctx context.Context, purpose common.OTPPurpose, email string) (*OTPSendStatus, error) {
+	status, err := s.sendOTPService.ReserveOTPSend(ctx, purpose, email)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil || !otpResendApplies(purpose, account) {
+		return status, nil
+	}
+
+	if err := s.sendOTPService.IssueOTP(ctx, purpose, email, mailer.ParseLocale(account.Locale)); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// otpResendApplies reports whether the account is in the flow of purpose.
+func otpResendApplies(purpose common.OTPPurpose, account *models.Account) bool {
+	switch purpose {
+	case common.OTPPurposeVerifyEmail:
+		return !account.IsVerified
+	case common.OTPPurposeResetPassword:
+		return true
+	default:
+		return false
+	}
+}
+
+// VerifyResetPasswordOTP consumes the reset-password OTP and returns a
+// single-use ticket that ResetPassword requires.
+func (s *AuthServiceImpl) VerifyResetPasswordOTP(ctx context.Context, email, otp string) (string, error) {
+	if _, err := s.VerifyOTP(ctx, common.OTPPurposeResetPassword, email, otp); err != nil {
+		return "", err
+	}
+
+	ticket, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("lỗi khi tạo phiên đặt lại mật khẩu: %w", err)
+	}
+
+	if err := s.redisStore.StoreResetTicket(ctx, utils.HashToken(ticket), email, ResetTicketTTL); err != nil {
+		return "", fmt.Errorf("lỗi khi lưu phiên đặt lại mật khẩu: %w", err)
+	}
+
+	return ticket, nil
+}
+
+// ResetPassword sets a new password for the account the ticket was issued
+// for and signs the account out everywhere.
+func (s *AuthServiceImpl) ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestResetPassword) error {
+	email, err := s.redisStore.ConsumeResetTicket(ctx, utils.HashToken(resetPasswordRequest.ResetToken))
+	if err != nil {
+		return fmt.Errorf("lỗi khi kiểm tra phiên đặt lại mật khẩu: %w", err)
+	}
+
+	if email == "" {
+		return ErrInvalidResetTicket
+	}
+
 	// Check if the account exists
-	account, err := s.accountRepository.GetByEmail(ctx, resetPasswordRequest.Email)
+	account, err := s.accountRepository.GetByEmail(ctx, email)
 	if err != nil {
 		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 
 	if account == nil {
-		return fmt.Errorf("tài khoản không tồn tại")
+		return ErrInvalidResetTicket
 	}
 
 	// Hash the new password
-	hashedPassword, err := utils.HashPassword(resetPasswordRequest.Password)
+	hashedPassword, err := utils.HashPassword(resetPasswordRequest.NewPassword)
 	if err != nil {
 		return fmt.Errorf("lỗi khi mã hóa mật khẩu: %w", err)
 	}
 
-	// Update the account's password
-	if err := s.accountRepository.Update(
-		ctx,
-		map[string]interface{}{"email": resetPasswordRequest.Email},
-		map[string]interface{}{"password_hash": hashedPassword},
-	); err != nil {
-		return fmt.Errorf("lỗi khi cập nhật mật khẩu: %w", err)
+	// Update the account's password and queue the alert with it
+	if err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": account.ID.String()},
+			map[string]interface{}{"password_hash": hashedPassword},
+		); err != nil {
+			return fmt.Errorf("lỗi khi cập nhật mật khẩu: %w", err)
+		}
+
+		return s.mailService.SendSecurityAlert(ctx, account, mailer.SecurityEventPasswordReset)
+	}); err != nil {
+		return err
 	}
 
-	return nil
+	// Whoever held the old password must not keep a session
+	return s.LogoutAll(ctx, account.ID.String())
 }
 
 func (s *AuthServiceImpl) ChangePassword(ctx context.Context, id string, changePasswordRequest *common.RequestChangePassword) error {
@@ -184,12 +418,12 @@ func (s *AuthServiceImpl) ChangePassword(ctx context.Context, id string, changeP
 	}
 
 	if account == nil {
-		return fmt.Errorf("tài khoản không tồn tại")
+		return ErrAccountNotFound
 	}
 
 	// Check if the old password matches
 	if !utils.ComparePasswordHash(account.Password, changePasswordRequest.OldPassword) {
-		return fmt.Errorf("mật khẩu cũ không chính xác")
+		return ErrWrongOldPassword
 	}
 	
 	// Hash the new password
@@ -198,44 +432,263 @@ func (s *AuthServiceImpl) ChangePassword(ctx context.Context, id string, changeP
 		return fmt.Errorf("lỗi khi mã hóa mật khẩu: %w", err)
 	}
 
-	// Update the account's password
+	// Update the account's password and queue the alert with it
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": id},
+			map[string]interface{}{"password_hash": hashedPassword},
+		); err != nil {
+			return fmt.Errorf("lỗi khi cập nhật mật khẩu: %w", err)
+		}
+
+		return s.mailService.SendSecurityAlert(ctx, account, mailer.SecurityEventPasswordChanged)
+	})
+}
+
+// ChangeLocale sets the language of the emails sent to the account.
+func (s *AuthServiceImpl) ChangeLocale(ctx context.Context, id, locale string) error {
 	if err := s.accountRepository.Update(
 		ctx,
 		map[string]interface{}{"id": id},
-		map[string]interface{}{"password_hash": hashedPassword},
+		map[string]interface{}{"locale": string(mailer.ParseLocale(locale))},
 	); err != nil {
-		return fmt.Errorf("lỗi khi cập nhật mật khẩu: %w", err)
+		return fmt.Errorf("lỗi khi cập nhật ngôn ngữ: %w", err)
 	}
 
 	return nil
 }
 
-func (s *AuthServiceImpl) RefreshToken(ctx context.Context, requestRefreshToken *common.RequestRefreshToken) (string, error) {
+// RefreshToken rotates the refresh token: every call returns a new pair and
+// invalidates the presented refresh token. Presenting a refresh token that was
+// already rotated is treated as theft and revokes the whole family.
+func (s *AuthServiceImpl) RefreshToken(ctx context.Context, requestRefreshToken *common.RequestRefreshToken, client ClientInfo) (*utils.TokenPair, error) {
 	// Verify the refresh token
-	account, err := s.tokenService.VerifyToken(requestRefreshToken.RefreshToken)
+	claims, err := s.tokenService.VerifyToken(requestRefreshToken.RefreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if claims.TokenType != utils.RefreshTokenType || claims.FamilyID == "" || claims.ID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Refresh tokens issued before a "logout everywhere" are dead as well
+	revoked, err := s.isRevokedForUser(ctx, claims)
 	if err != nil {
-		return "", fmt.Errorf("lỗi khi xác thực refresh token: %w", err)
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	// A revoked session must not come back through its refresh token
+	session, err := s.sessionRepository.GetById(ctx, claims.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy phiên đăng nhập: %w", err)
+	}
+	if session != nil && session.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+
+	// Load the account so a lock, or a role change, applies from this refresh on
+	account, err := s.accountRepository.GetAccountById(ctx, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 
-	// If the account is nil, it means the token is invalid or expired
 	if account == nil {
-		return "", fmt.Errorf("refresh token không hợp lệ hoặc đã hết hạn")
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if err := checkAccountState(models.NewAccountState(account, now), now); err != nil {
+		return nil, err
+	}
+
+	// Generate the next pair in the same family
+	tokens, err := s.tokenService.GenerateTokens(*account, claims.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo token mới: %w", err)
+	}
+
+	rotated, err := s.redisStore.RotateRefreshToken(
+		ctx,
+		claims.FamilyID,
+		claims.ID,
+		tokens.RefreshID,
+		time.Until(tokens.RefreshExpiresAt),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu refresh token: %w", err)
+	}
+
+	if !rotated {
+		// The token is valid but no longer current: it was reused, or the
+		// family was already revoked. Either way nothing in it may survive.
+		if err := s.revokeSession(ctx, claims.FamilyID); err != nil {
+			return nil, err
+		}
+		notifySecurityEvent(ctx, s.mailService, account, mailer.SecurityEventTokenReuse)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if session != nil {
+		if err := s.sessionRepository.Touch(ctx, claims.FamilyID, client.IP, now, tokens.RefreshExpiresAt); err != nil {
+			return nil, fmt.Errorf("lỗi khi cập nhật phiên đăng nhập: %w", err)
+		}
+	}
+
+	return tokens, nil
+}
+
+// AuthenticateAccessToken verifies an access token and checks it against the
+// denylist written by Logout and LogoutAll. The role of the returned claims is
+// the current role of the account.
+func (s *AuthServiceImpl) AuthenticateAccessToken(ctx context.Context, accessToken string) (*utils.TokenClaims, error) {
+	claims, err := s.tokenService.VerifyToken(accessToken)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	// Refresh tokens may only be exchanged at /auth/token/refresh
+	if claims.TokenType == utils.RefreshTokenType {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if claims.ID != "" {
+		revoked, err := s.redisStore.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi khi kiểm tra token: %w", err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	revoked, err := s.isRevokedForUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	// The access token dies with its session
+	if claims.FamilyID != "" {
+		active, err := s.redisStore.IsRefreshFamilyActive(ctx, claims.FamilyID)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi khi kiểm tra token: %w", err)
+		}
+		if !active {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	state, err := s.getAccountState(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrInvalidAccessToken
 	}
 
-	var accountTemp models.Account
-	// If the account is nil, try to get it by ID
-	parsedUUID, err := uuid.Parse(account.ID)
+	if err := checkAccountState(state, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Permissions follow the role the account has now, not the one it had
+	// when the token was issued
+	claims.Role = state.Role
+
+	return claims, nil
+}
+
+// getAccountState reads the account state through a short-lived cache so
+// authenticated requests do not hit the database every time. It returns nil
+// when the account no longer exists.
+func (s *AuthServiceImpl) getAccountState(ctx context.Context, accountID string) (*models.AccountState, error) {
+	state, err := s.redisStore.GetAccountState(ctx, accountID)
 	if err != nil {
-		return "", fmt.Errorf("lỗi khi chuyển đổi ID: %w", err)
+		return nil, fmt.Errorf("lỗi khi lấy trạng thái tài khoản: %w", err)
+	}
+
+	if state != nil {
+		return state, nil
+	}
+
+	account, err := s.accountRepository.GetAccountById(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return nil, nil
+	}
+
+	state = models.NewAccountState(account, time.Now())
+	if err := s.redisStore.SetAccountState(ctx, accountID, state, accountStateCacheTTL); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu trạng thái tài khoản: %w", err)
+	}
+
+	return state, nil
+}
+
+// Logout revokes the presented access token and the refresh token family it
+// was issued with, so the device has to log in again.
+func (s *AuthServiceImpl) Logout(ctx context.Context, claims *utils.TokenClaims) error {
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.redisStore.RevokeAccessToken(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+			return fmt.Errorf("lỗi khi thu hồi access token: %w", err)
+		}
+	}
+
+	if claims.FamilyID != "" {
+		return s.revokeSession(ctx, claims.FamilyID)
+	}
+
+	return nil
+}
+
+// revokeSession drops the refresh token family and marks its session revoked.
+func (s *AuthServiceImpl) revokeSession(ctx context.Context, familyID string) error {
+	if err := s.redisStore.RevokeRefreshFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi refresh token: %w", err)
+	}
+
+	if err := s.sessionRepository.Revoke(ctx, familyID, time.Now()); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi phiên đăng nhập: %w", err)
 	}
-	accountTemp.ID = parsedUUID
-	accountTemp.Role = account.Role
 
-	// Generate new access and refresh tokens
-	newAccessToken, _, err := s.tokenService.GenerateTokens(accountTemp)
+	return nil
+}
+
+// LogoutAll revokes every token of the user issued up to now. The mark only
+// has to live as long as the longest-lived token.
+func (s *AuthServiceImpl) LogoutAll(ctx context.Context, userID string) error {
+	now := time.Now()
+	if err := s.redisStore.RevokeUserTokens(ctx, userID, now, utils.RefreshTokenTTL); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi phiên đăng nhập: %w", err)
+	}
+
+	if err := s.sessionRepository.RevokeAllByAccountId(ctx, userID, now); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi phiên đăng nhập: %w", err)
+	}
+	return nil
+}
+
+func (s *AuthServiceImpl) isRevokedForUser(ctx context.Context, claims *utils.TokenClaims) (bool, error) {
+	revokedAt, err := s.redisStore.GetUserTokensRevokedAt(ctx, claims.UserID)
 	if err != nil {
-		return "", fmt.Errorf("lỗi khi tạo token mới: %w", err)
+		return false, fmt.Errorf("lỗi khi kiểm tra token: %w", err)
+	}
+
+	if revokedAt.IsZero() {
+		return false, nil
 	}
 
-	return newAccessToken, nil
+	// Both times have millisecond precision, so a token issued right after
+	// the revocation, even in the same second, stays valid. A token without
+	// iat_ms only has its second, which errs on the side of revoking it.
+	return claims.IssuedAtTime().Before(revokedAt), nil
 }
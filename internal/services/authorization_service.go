@@ -0,0 +1,191 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rolePermissionCacheTTL bounds how long a change of the role-permission
+// table made by another instance can take to apply here.
+const rolePermissionCacheTTL = 30 * time.Second
+
+var (
+	ErrRoleNotFound        = common.NewNotFound("ROLE_NOT_FOUND", "không tìm thấy vai trò")
+	ErrUnknownPermission   = common.NewValidation("UNKNOWN_PERMISSION", "quyền không tồn tại")
+	ErrProtectedPermission = common.NewConflict("PROTECTED_PERMISSION", "không thể thu hồi quyền quản lý vai trò của quản trị viên")
+)
+
+// AuthorizationService answers which permissions a role grants and lets
+// admins change them.
+type AuthorizationService interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+	ListPermissions(ctx context.Context) ([]*models.Permission, error)
+	UpdateRolePermissions(ctx context.Context, role string, request *dto.UpdateRolePermissionsRequest) (*models.Role, error)
+}
+
+type AuthorizationServiceImpl struct {
+	roleRepository repositories.RoleRepository
+	txManager      repositories.TxManager
+
+	// grants caches role_permissions as role -> permission, it is checked on
+	// every request behind RequirePermission. generation counts the
+	// invalidations, so a load that started before one is not cached.
+	mu         sync.RWMutex
+	grants     map[string]map[string]bool
+	loadedAt   time.Time
+	generation uint64
+}
+
+func NewAuthorizationServiceImpl(roleRepo repositories.RoleRepository, txManager repositories.TxManager) *AuthorizationServiceImpl {
+	return &AuthorizationServiceImpl{
+		roleRepository: roleRepo,
+		txManager:      txManager,
+	}
+}
+
+func (s *AuthorizationServiceImpl) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	grants, err := s.loadGrants(ctx)
+	if err != nil {
+		return false, fmt.Errorf("lỗi khi lấy quyền của vai trò: %w", err)
+	}
+
+	return grants[role][permission], nil
+}
+
+func (s *AuthorizationServiceImpl) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	roles, err := s.roleRepository.GetListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách vai trò: %w", err)
+	}
+
+	rolePermissions, err := s.roleRepository.GetListRolePermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy quyền của vai trò: %w", err)
+	}
+
+	permissionsByRole := make(map[string][]string)
+	for _, rolePermission := range rolePermissions {
+		permissionsByRole[rolePermission.Role] = append(permissionsByRole[rolePermission.Role], rolePermission.Permission)
+	}
+	for _, role := range roles {
+		role.Permissions = permissionsByRole[role.Name]
+	}
+
+	return roles, nil
+}
+
+func (s *AuthorizationServiceImpl) ListPermissions(ctx context.Context) ([]*models.Permission, error) {
+	permissions, err := s.roleRepository.GetListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách quyền: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// UpdateRolePermissions replaces the permissions of role with the ones of the
+// request. The admin role always keeps role:manage, so the table cannot be
+// locked against every account.
+func (s *AuthorizationServiceImpl) UpdateRolePermissions(ctx context.Context, roleName string, request *dto.UpdateRolePermissionsRequest) (*models.Role, error) {
+	role, err := s.roleRepository.GetRole(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy vai trò: %w", err)
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	permissions, err := s.roleRepository.GetListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách quyền: %w", err)
+	}
+	known := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		known[permission.Name] = true
+	}
+
+	granted := make([]string, 0, len(request.Permissions))
+	seen := make(map[string]bool, len(request.Permissions))
+	var unknown []string
+	for _, permission := range request.Permissions {
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+
+		if !known[permission] {
+			unknown = append(unknown, permission)
+			continue
+		}
+		granted = append(granted, permission)
+	}
+	if len(unknown) > 0 {
+		return nil, ErrUnknownPermission.WithDetails(map[string]interface{}{
+			"permissions": unknown,
+		})
+	}
+	if role.Name == models.RoleAdmin && !seen[models.PermissionRoleManage] {
+		return nil, ErrProtectedPermission
+	}
+	sort.Strings(granted)
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.roleRepository.ReplaceRolePermissions(ctx, role.Name, granted)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi cập nhật quyền của vai trò: %w", err)
+	}
+	s.invalidateGrants()
+
+	role.Permissions = granted
+	return role, nil
+}
+
+// loadGrants returns the cached role-permission table, reading it again once
+// it is older than rolePermissionCacheTTL.
+func (s *AuthorizationServiceImpl) loadGrants(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	grants, loadedAt, generation := s.grants, s.loadedAt, s.generation
+	s.mu.RUnlock()
+	if grants != nil && time.Since(loadedAt) < rolePermissionCacheTTL {
+		return grants, nil
+	}
+
+	rolePermissions, err := s.roleRepository.GetListRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grants = make(map[string]map[string]bool)
+	for _, rolePermission := range rolePermissions {
+		if grants[rolePermission.Role] == nil {
+			grants[rolePermission.Role] = make(map[string]bool)
+		}
+		grants[rolePermission.Role][rolePermission.Permission] = true
+	}
+
+	// The table read may predate an invalidation made meanwhile: it still
+	// answers this call, but is not cached
+	s.mu.Lock()
+	if s.generation == generation {
+		s.grants, s.loadedAt = grants, time.Now()
+	}
+	s.mu.Unlock()
+
+	return grants, nil
+}
+
+func (s *AuthorizationServiceImpl) invalidateGrants() {
+	s.mu.Lock()
+	s.grants = nil
+	s.generation++
+	s.mu.Unlock()
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrEmailNotFound     = common.NewNotFound("EMAIL_NOT_FOUND", "không tìm thấy email")
+	ErrEmailNotRetryable = common.NewConflict("EMAIL_NOT_RETRYABLE", "chỉ có thể gửi lại email đã thất bại")
+)
+
+// EmailOutboxService lets admins follow the delivery of the emails.
+type EmailOutboxService interface {
+	ListEmails(ctx context.Context, paging *common.Paging, status string) ([]*models.EmailOutbox, error)
+	GetEmail(ctx context.Context, id int64) (*models.EmailOutbox, error)
+	RetryEmail(ctx context.Context, id int64) error
+}
+
+type EmailOutboxServiceImpl struct {
+	outboxRepository repositories.EmailOutboxRepository
+}
+
+func NewEmailOutboxServiceImpl(outboxRepo repositories.EmailOutboxRepository) *EmailOutboxServiceImpl {
+	return &EmailOutboxServiceImpl{outboxRepository: outboxRepo}
+}
+
+func (s *EmailOutboxServiceImpl) ListEmails(ctx context.Context, paging *common.Paging, status string) ([]*models.EmailOutbox, error) {
+	paging.ProcessPaging()
+	if err := paging.ProcessSort([]string{common.CursorSort}, common.CursorSort); err != nil {
+		return nil, err
+	}
+
+	cond := map[string]interface{}{}
+	if status != "" {
+		cond["status"] = status
+	}
+
+	emails, err := s.outboxRepository.GetList(ctx, paging, cond)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách email: %w", err)
+	}
+
+	return emails, nil
+}
+
+func (s *EmailOutboxServiceImpl) GetEmail(ctx context.Context, id int64) (*models.EmailOutbox, error) {
+	email, err := s.outboxRepository.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy email: %w", err)
+	}
+
+	if email == nil {
+		return nil, ErrEmailNotFound
+	}
+
+	return email, nil
+}
+
+// RetryEmail queues a dead-lettered email again with a fresh attempt budget.
+func (s *EmailOutboxServiceImpl) RetryEmail(ctx context.Context, id int64) error {
+	if _, err := s.GetEmail(ctx, id); err != nil {
+		return err
+	}
+
+	retried, err := s.outboxRepository.Retry(ctx, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("lỗi khi gửi lại email: %w", err)
+	}
+
+	if !retried {
+		return ErrEmailNotRetryable
+	}
+
+	return nil
+}
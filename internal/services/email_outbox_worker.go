@@ -0,0 +1,135 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	emailBatchSize = 20
+	// A claimed email is retried after emailClaimLease if its worker died
+	// before recording the outcome.
+	emailClaimLease = 2 * time.Minute
+
+	emailRetryBaseDelay = 30 * time.Second
+	emailRetryMaxDelay  = time.Hour
+)
+
+// EmailOutboxWorker delivers the emails of the outbox. A failed delivery is
+// retried with exponential backoff and the email is dead-lettered after
+// maxAttempts; several workers can run side by side.
+type EmailOutboxWorker struct {
+	outboxRepository repositories.EmailOutboxRepository
+	mailer           mailer.Mailer
+	renderer         *mailer.Renderer
+	pollInterval     time.Duration
+	maxAttempts      int
+}
+
+func NewEmailOutboxWorker(
+	outboxRepo repositories.EmailOutboxRepository,
+	m mailer.Mailer,
+	renderer *mailer.Renderer,
+	pollInterval time.Duration,
+	maxAttempts int,
+) *EmailOutboxWorker {
+	return &EmailOutboxWorker{
+		outboxRepository: outboxRepo,
+		mailer:           m,
+		renderer:         renderer,
+		pollInterval:     pollInterval,
+		maxAttempts:      maxAttempts,
+	}
+}
+
+// Run delivers due emails until ctx is cancelled.
+func (w *EmailOutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back, then wait for the next tick
+		for {
+			processed, err := w.ProcessDue(ctx)
+			if err != nil {
+				log.Printf("Process email outbox failed: %v", err)
+				break
+			}
+			if processed < emailBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue delivers one batch of due emails and returns how many it took.
+func (w *EmailOutboxWorker) ProcessDue(ctx context.Context) (int, error) {
+	emails, err := w.outboxRepository.ClaimDue(ctx, time.Now(), emailBatchSize, emailClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		if err := w.deliver(ctx, email); err != nil {
+			log.Printf("Record delivery of email %d failed: %v", email.ID, err)
+		}
+	}
+
+	return len(emails), nil
+}
+
+// deliver sends one claimed email and records the outcome.
+func (w *EmailOutboxWorker) deliver(ctx context.Context, email *models.EmailOutbox) error {
+	now := time.Now()
+	if email.ExpiresAt != nil && !now.Before(*email.ExpiresAt) {
+		return w.outboxRepository.MarkExpired(ctx, email.ID)
+	}
+
+	sendErr := w.send(ctx, email)
+	if sendErr == nil {
+		return w.outboxRepository.MarkSent(ctx, email.ID, time.Now())
+	}
+
+	if email.Attempts >= w.maxAttempts {
+		return w.outboxRepository.MarkDead(ctx, email.ID, sendErr.Error())
+	}
+
+	return w.outboxRepository.MarkFailed(ctx, email.ID, now.Add(emailRetryDelay(email.Attempts)), sendErr.Error())
+}
+
+func (w *EmailOutboxWorker) send(ctx context.Context, email *models.EmailOutbox) error {
+	name := mailer.Template(email.Template)
+	data, err := mailer.DecodeData(name, []byte(email.Payload))
+	if err != nil {
+		return err
+	}
+
+	msg, err := w.renderer.Render(name, mailer.Locale(email.Locale), email.Recipient, data)
+	if err != nil {
+		return err
+	}
+
+	return w.mailer.Send(ctx, msg)
+}
+
+// emailRetryDelay doubles the wait after every failed attempt.
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBaseDelay
+	for i := 1; i < attempts && delay < emailRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > emailRetryMaxDelay {
+		delay = emailRetryMaxDelay
+	}
+	return delay
+}
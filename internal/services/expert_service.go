@@ -1,6 +1,8 @@
 package services
 
 import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
@@ -8,31 +10,90 @@ import (
 	"fmt"
 )
 
+var (
+	ErrExpertAccountExists = common.NewConflict("EXPERT_ACCOUNT_EXISTS", "email của chuyên gia đã được dùng cho một tài khoản khác")
+	ErrInvalidPhoneNumber  = common.NewValidation("INVALID_PHONE_NUMBER", "Số điện thoại không hợp lệ")
+)
+
 type ExpertService interface {
-	CreateExpert(ctx context.Context, createExpertRequest *models.ExpertCreate) (*models.ExpertCreate, error)
+	CreateExpert(ctx context.Context, createExpertRequest *dto.CreateExpertRequest, avatarURL string) (*models.Expert, error)
 }
 
 type ExpertServiceImpl struct {
-	expertRepo repositories.ExpertRepository
+	expertRepo  repositories.ExpertRepository
+	accountRepo repositories.AccountRepository
+	mailService MailService
+	txManager   repositories.TxManager
 }
 
-func NewExpertService(repo repositories.ExpertRepository) *ExpertServiceImpl {
-	return &ExpertServiceImpl{expertRepo: repo}
+func NewExpertService(
+	repo repositories.ExpertRepository,
+	accountRepo repositories.AccountRepository,
+	mailService MailService,
+	txManager repositories.TxManager,
+) *ExpertServiceImpl {
+	return &ExpertServiceImpl{
+		expertRepo:  repo,
+		accountRepo: accountRepo,
+		mailService: mailService,
+		txManager:   txManager,
+	}
 }
 
-func (s *ExpertServiceImpl) CreateExpert(ctx context.Context, createExpertRequest *models.ExpertCreate) (
-	*models.ExpertCreate,
+// CreateExpert creates the expert together with its login account. The
+// account gets a random password, the expert sets their own through the
+// forgot-password flow.
+func (s *ExpertServiceImpl) CreateExpert(ctx context.Context, createExpertRequest *dto.CreateExpertRequest, avatarURL string) (
+	*models.Expert,
 	error,
 ) {
-	if createExpertRequest.TelephoneNumber != "" {
-		if !utils.IsValidVietnamesePhoneNumber(createExpertRequest.TelephoneNumber) {
-			return nil, fmt.Errorf("Số điện thoại không hợp lệ")
+	expert := createExpertRequest.ToExpert(avatarURL)
+	if expert.TelephoneNumber != "" {
+		if !utils.IsValidVietnamesePhoneNumber(expert.TelephoneNumber) {
+			return nil, ErrInvalidPhoneNumber
 		}
 	}
 
-	if err := s.expertRepo.Create(ctx, createExpertRequest); err != nil {
+	existsAccount, err := s.accountRepo.GetByEmail(ctx, expert.Email)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi kiểm tra tài khoản: %w", err)
+	}
+	if existsAccount != nil {
+		return nil, ErrExpertAccountExists
+	}
+
+	password, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo mật khẩu: %w", err)
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi mã hóa mật khẩu: %w", err)
+	}
+
+	account := &models.Account{
+		Email:         expert.Email,
+		Password:      hashedPassword,
+		Role:          models.RoleExpert,
+		IsVerified:    true,
+		AccountStatus: true,
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepo.Create(ctx, account); err != nil {
+			return fmt.Errorf("lỗi khi tạo tài khoản: %w", err)
+		}
+
+		expert.AccountID = &account.ID
+		if err := s.expertRepo.Create(ctx, expert); err != nil {
+			return fmt.Errorf("lỗi khi tạo chuyên gia: %w", err)
+		}
+
+		return s.mailService.SendWelcome(ctx, account)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return createExpertRequest, nil
+	return expert, nil
 }
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials   = common.NewUnauthorized("INVALID_CREDENTIALS", "email hoặc mật khẩu không chính xác")
+	ErrTooManyLoginAttempts = common.NewRateLimited("TOO_MANY_LOGIN_ATTEMPTS", "đăng nhập sai quá nhiều lần")
+)
+
+// loginRateLimitError is returned while logins are locked for the account or
+// the client IP.
+func loginRateLimitError(retryAfter time.Duration) *common.AppError {
+	message := fmt.Sprintf("%s, vui lòng thử lại sau %d giây", ErrTooManyLoginAttempts.Message, int(math.Ceil(retryAfter.Seconds())))
+	return ErrTooManyLoginAttempts.WithMessage(message).WithRetryAfter(retryAfter)
+}
+
+// loginThrottle locks logins once failures within the window reach the
+// threshold. Every further failure doubles the lock, up to maxDelay.
+type loginThrottle struct {
+	threshold int64
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	window    time.Duration
+}
+
+var (
+	accountLoginThrottle = loginThrottle{threshold: 5, baseDelay: 30 * time.Second, maxDelay: 15 * time.Minute, window: time.Hour}
+	ipLoginThrottle      = loginThrottle{threshold: 20, baseDelay: time.Minute, maxDelay: time.Hour, window: time.Hour}
+)
+
+func (t loginThrottle) lockDuration(failures int64) time.Duration {
+	if failures < t.threshold {
+		return 0
+	}
+
+	delay := t.baseDelay
+	for i := t.threshold; i < failures && delay < t.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > t.maxDelay {
+		return t.maxDelay
+	}
+	return delay
+}
+
+func loginAccountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// mfaAccountKey counts the wrong passwords and codes an authenticated account
+// sends when it confirms or disables 2FA.
+func mfaAccountKey(accountID string) string {
+	return "mfa:" + accountID
+}
+
+// dummyPasswordHash is compared against when the account does not exist, so
+// both failures take the same time.
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+func compareDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// checkLoginLock fails while either the account or the client IP is locked.
+func checkLoginLock(ctx context.Context, store repositories.RedisStore, email, ip string) error {
+	return checkLocks(ctx, store, loginAccountKey(email), loginIPKey(ip))
+}
+
+// recordLoginFailure counts the failure for the account and the IP and
+// starts a lock when a throttle says so.
+func recordLoginFailure(ctx context.Context, store repositories.RedisStore, email, ip string) error {
+	return recordFailures(ctx, store, map[string]loginThrottle{
+		loginAccountKey(email): accountLoginThrottle,
+		loginIPKey(ip):         ipLoginThrottle,
+	})
+}
+
+// checkMFALock fails while the 2FA checks of the account are locked.
+func checkMFALock(ctx context.Context, store repositories.RedisStore, accountID string) error {
+	return checkLocks(ctx, store, mfaAccountKey(accountID))
+}
+
+// recordMFAFailure counts a wrong password or code of the account, with the
+// same throttle as its logins.
+func recordMFAFailure(ctx context.Context, store repositories.RedisStore, accountID string) error {
+	return recordFailures(ctx, store, map[string]loginThrottle{
+		mfaAccountKey(accountID): accountLoginThrottle,
+	})
+}
+
+// checkLocks fails with the longest remaining lock of the keys.
+func checkLocks(ctx context.Context, store repositories.RedisStore, keys ...string) error {
+	var retryAfter time.Duration
+	for _, key := range keys {
+		ttl, err := store.GetLoginLock(ctx, key)
+		if err != nil {
+			return fmt.Errorf("lỗi khi kiểm tra khóa đăng nhập: %w", err)
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+
+	if retryAfter > 0 {
+		return loginRateLimitError(retryAfter)
+	}
+	return nil
+}
+
+func recordFailures(ctx context.Context, store repositories.RedisStore, throttles map[string]loginThrottle) error {
+	for key, throttle := range throttles {
+		failures, err := store.RecordLoginFailure(ctx, key, throttle.window)
+		if err != nil {
+			return fmt.Errorf("lỗi khi ghi nhận đăng nhập sai: %w", err)
+		}
+
+		if delay := throttle.lockDuration(failures); delay > 0 {
+			if err := store.LockLogin(ctx, key, delay); err != nil {
+				return fmt.Errorf("lỗi khi khóa đăng nhập: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// MailService queues the emails of the service, in the account's language,
+// in the email outbox. EmailOutboxWorker delivers them. Called within a
+// transaction of TxManager, the email is only queued if the transaction
+// commits.
+type MailService interface {
+	SendOTP(ctx context.Context, locale mailer.Locale, email string, purpose common.OTPPurpose, otp string) error
+	SendWelcome(ctx context.Context, account *models.Account) error
+	SendSecurityAlert(ctx context.Context, account *models.Account, event mailer.SecurityEvent) error
+	SendSetPassword(ctx context.Context, account *models.Account) error
+}
+
+type MailServiceImpl struct {
+	outboxRepository repositories.EmailOutboxRepository
+}
+
+func NewMailServiceImpl(outboxRepo repositories.EmailOutboxRepository) *MailServiceImpl {
+	return &MailServiceImpl{outboxRepository: outboxRepo}
+}
+
+// SendOTP queues the code with the lifetime of the code, so the worker does
+// not deliver a code that can no longer be used.
+func (s *MailServiceImpl) SendOTP(ctx context.Context, locale mailer.Locale, email string, purpose common.OTPPurpose, otp string) error {
+	expiresAt := time.Now().Add(otpTTL)
+	return s.enqueue(ctx, mailer.TemplateOTP, locale, email, &expiresAt, mailer.OTPData{
+		Code:             otp,
+		Purpose:          string(purpose),
+		ExpiresInMinutes: int(otpTTL / time.Minute),
+	})
+}
+
+func (s *MailServiceImpl) SendWelcome(ctx context.Context, account *models.Account) error {
+	return s.enqueue(ctx, mailer.TemplateWelcome, mailer.ParseLocale(account.Locale), account.Email, nil, mailer.WelcomeData{
+		Email: account.Email,
+	})
+}
+
+func (s *MailServiceImpl) SendSecurityAlert(ctx context.Context, account *models.Account, event mailer.SecurityEvent) error {
+	return s.enqueue(ctx, mailer.TemplateSecurityAlert, mailer.ParseLocale(account.Locale), account.Email, nil, mailer.SecurityAlertData{
+		Event: event,
+		Time:  time.Now(),
+	})
+}
+
+func (s *MailServiceImpl) SendSetPassword(ctx context.Context, account *models.Account) error {
+	return s.enqueue(ctx, mailer.TemplateSetPassword, mailer.ParseLocale(account.Locale), account.Email, nil, mailer.SetPasswordData{
+		Email: account.Email,
+	})
+}
+
+func (s *MailServiceImpl) enqueue(ctx context.Context, name mailer.Template, locale mailer.Locale, to string, expiresAt *time.Time, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("tạo dữ liệu email thất bại: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.outboxRepository.Create(ctx, &models.EmailOutbox{
+		Template:      string(name),
+		Locale:        string(locale),
+		Recipient:     to,
+		Payload:       string(payload),
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: now,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}); err != nil {
+		return fmt.Errorf("lưu email vào hàng đợi thất bại: %w", err)
+	}
+	return nil
+}
+
+// notifySecurityEvent queues a security alert without failing the change that
+// triggered it, for changes that are not made in a database transaction.
+func notifySecurityEvent(ctx context.Context, mailService MailService, account *models.Account, event mailer.SecurityEvent) {
+	if err := mailService.SendSecurityAlert(ctx, account, event); err != nil {
+		log.Printf("Queue security alert %s to %s failed: %v", event, account.Email, err)
+	}
+}
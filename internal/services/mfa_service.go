@@ -0,0 +1,439 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	recoveryCodeCount       = 10
+	// A TOTP code is accepted one step either side of now, so a used step
+	// has to be remembered for three steps.
+	totpStepUsedTTL = 90 * time.Second
+)
+
+var (
+	ErrMFAAlreadyEnabled   = common.NewConflict("MFA_ALREADY_ENABLED", "xác thực hai lớp đã được bật")
+	ErrMFANotEnabled       = common.NewConflict("MFA_NOT_ENABLED", "xác thực hai lớp chưa được bật")
+	ErrMFASetupRequired    = common.NewValidation("MFA_SETUP_REQUIRED", "chưa khởi tạo xác thực hai lớp")
+	ErrMFARequiredByPolicy = common.NewForbidden("MFA_REQUIRED_BY_POLICY", "tài khoản bắt buộc sử dụng xác thực hai lớp")
+	ErrInvalidMFACode      = common.NewUnauthorized("INVALID_MFA_CODE", "mã xác thực không chính xác")
+	ErrInvalidMFAChallenge = common.NewUnauthorized("INVALID_MFA_CHALLENGE", "phiên xác thực hai lớp không hợp lệ hoặc đã hết hạn")
+)
+
+// TOTPSetup is shown once to the user to register the authenticator app.
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// MFAChallenge is returned by Login instead of tokens when a second factor is
+// needed. EnrollmentRequired is set when policy demands 2FA for an account
+// that has not enrolled yet.
+type MFAChallenge struct {
+	Token              string
+	EnrollmentRequired bool
+	ExpiresIn          time.Duration
+}
+
+type MFAService interface {
+	IsRequired(account *models.Account) bool
+	CreateChallenge(ctx context.Context, account *models.Account) (*MFAChallenge, error)
+	VerifyChallenge(ctx context.Context, verifyRequest *common.RequestMFAVerify) (*models.Account, []string, error)
+	SetupTOTP(ctx context.Context, accountID string) (*TOTPSetup, error)
+	SetupTOTPForChallenge(ctx context.Context, mfaToken string) (*TOTPSetup, error)
+	ConfirmTOTP(ctx context.Context, accountID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, accountID string, disableRequest *common.RequestDisableTOTP) error
+}
+
+type MFAServiceImpl struct {
+	accountRepository      repositories.AccountRepository
+	recoveryCodeRepository repositories.RecoveryCodeRepository
+	redisStore             repositories.RedisStore
+	mailService            MailService
+	txManager              repositories.TxManager
+}
+
+func NewMFAServiceImpl(
+	accountRepo repositories.AccountRepository,
+	recoveryCodeRepo repositories.RecoveryCodeRepository,
+	redis repositories.RedisStore,
+	mailService MailService,
+	txManager repositories.TxManager,
+) *MFAServiceImpl {
+	return &MFAServiceImpl{
+		accountRepository:      accountRepo,
+		recoveryCodeRepository: recoveryCodeRepo,
+		redisStore:             redis,
+		mailService:            mailService,
+		txManager:              txManager,
+	}
+}
+
+// IsRequired reports whether policy forces the account's role into 2FA.
+func (s *MFAServiceImpl) IsRequired(account *models.Account) bool {
+	for _, role := range config.AppConfig.MFARequiredRoles {
+		if account.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MFAServiceImpl) CreateChallenge(ctx context.Context, account *models.Account) (*MFAChallenge, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo phiên xác thực hai lớp: %w", err)
+	}
+
+	if err := s.redisStore.StoreMFAChallenge(ctx, utils.HashToken(token), account.ID.String(), mfaChallengeTTL); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu phiên xác thực hai lớp: %w", err)
+	}
+
+	return &MFAChallenge{
+		Token:              token,
+		EnrollmentRequired: !account.TOTPEnabled,
+		ExpiresIn:          mfaChallengeTTL,
+	}, nil
+}
+
+// VerifyChallenge completes a login challenge with a TOTP code or a recovery
+// code. For an account enrolling because of policy, the code confirms the
+// enrollment and the new recovery codes are returned. The challenge is
+// single-use and dropped after too many wrong codes; the wrong codes of every
+// challenge also lock the account with limitGuesses.
+func (s *MFAServiceImpl) VerifyChallenge(ctx context.Context, verifyRequest *common.RequestMFAVerify) (*models.Account, []string, error) {
+	challengeHash := utils.HashToken(verifyRequest.MFAToken)
+	accountID, err := s.redisStore.GetMFAChallenge(ctx, challengeHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi khi lấy phiên xác thực hai lớp: %w", err)
+	}
+
+	if accountID == "" {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	account, err := s.accountRepository.GetAccountById(ctx, accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	var recoveryCodes []string
+	switch {
+	case !account.TOTPEnabled:
+		recoveryCodes, err = s.ConfirmTOTP(ctx, accountID, verifyRequest.Code)
+	case verifyRequest.RecoveryCode != "":
+		err = s.limitGuesses(ctx, account, func() error {
+			return s.useRecoveryCode(ctx, account, verifyRequest.RecoveryCode)
+		})
+	default:
+		err = s.checkCode(ctx, account, verifyRequest.Code)
+	}
+
+	if errors.Is(err, ErrInvalidMFACode) {
+		failures, recordErr := s.redisStore.RecordMFAChallengeFailure(ctx, challengeHash, mfaChallengeTTL)
+		if recordErr != nil {
+			return nil, nil, fmt.Errorf("lỗi khi ghi nhận mã xác thực sai: %w", recordErr)
+		}
+
+		if failures >= mfaChallengeMaxAttempts {
+			if err := s.redisStore.DeleteMFAChallenge(ctx, challengeHash); err != nil {
+				return nil, nil, fmt.Errorf("lỗi khi hủy phiên xác thực hai lớp: %w", err)
+			}
+		}
+		return nil, nil, err
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.redisStore.DeleteMFAChallenge(ctx, challengeHash); err != nil {
+		return nil, nil, fmt.Errorf("lỗi khi hủy phiên xác thực hai lớp: %w", err)
+	}
+
+	return account, recoveryCodes, nil
+}
+
+// SetupTOTP generates a new secret for the account. 2FA is only enabled once
+// ConfirmTOTP has seen a code produced from it.
+func (s *MFAServiceImpl) SetupTOTP(ctx context.Context, accountID string) (*TOTPSetup, error) {
+	account, err := s.getAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi tạo khóa xác thực hai lớp: %w", err)
+	}
+
+	encryptedSecret, err := utils.EncryptString(config.AppConfig.MFAEncryptionKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi mã hóa khóa xác thực hai lớp: %w", err)
+	}
+
+	if err := s.accountRepository.Update(
+		ctx,
+		map[string]interface{}{"id": accountID},
+		map[string]interface{}{"totp_secret": encryptedSecret},
+	); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu khóa xác thực hai lớp: %w", err)
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    utils.TOTPURI(config.AppConfig.MFAIssuer, account.Email, secret),
+	}, nil
+}
+
+// SetupTOTPForChallenge lets an account that policy forces into 2FA enroll
+// during login, before it holds any token.
+func (s *MFAServiceImpl) SetupTOTPForChallenge(ctx context.Context, mfaToken string) (*TOTPSetup, error) {
+	accountID, err := s.redisStore.GetMFAChallenge(ctx, utils.HashToken(mfaToken))
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy phiên xác thực hai lớp: %w", err)
+	}
+
+	if accountID == "" {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	return s.SetupTOTP(ctx, accountID)
+}
+
+// ConfirmTOTP enables 2FA once the user proves the authenticator app works
+// and returns the recovery codes, which are shown only this once.
+func (s *MFAServiceImpl) ConfirmTOTP(ctx context.Context, accountID, code string) ([]string, error) {
+	account, err := s.getAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if account.TOTPSecret == "" {
+		return nil, ErrMFASetupRequired
+	}
+
+	if err := s.checkCode(ctx, account, code); err != nil {
+		return nil, err
+	}
+
+	// Enabling, the recovery codes and the alert are committed together
+	var recoveryCodes []string
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": accountID},
+			map[string]interface{}{"totp_enabled": true, "totp_enabled_at": time.Now()},
+		); err != nil {
+			return fmt.Errorf("lỗi khi bật xác thực hai lớp: %w", err)
+		}
+
+		recoveryCodes, err = s.generateRecoveryCodes(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return s.mailService.SendSecurityAlert(ctx, account, mailer.SecurityEventMFAEnabled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTOTP turns 2FA off once the password and a current code are given.
+// A wrong password counts towards the same lock as a wrong code.
+func (s *MFAServiceImpl) DisableTOTP(ctx context.Context, accountID string, disableRequest *common.RequestDisableTOTP) error {
+	account, err := s.getAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if !account.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if s.IsRequired(account) {
+		return ErrMFARequiredByPolicy
+	}
+
+	if err := checkMFALock(ctx, s.redisStore, accountID); err != nil {
+		return err
+	}
+
+	if !utils.ComparePasswordHash(account.Password, disableRequest.Password) {
+		if err := recordMFAFailure(ctx, s.redisStore, accountID); err != nil {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+
+	if err := s.checkCode(ctx, account, disableRequest.Code); err != nil {
+		return err
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": accountID},
+			map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_enabled_at": nil},
+		); err != nil {
+			return fmt.Errorf("lỗi khi tắt xác thực hai lớp: %w", err)
+		}
+
+		if err := s.recoveryCodeRepository.DeleteForAccount(ctx, account.ID); err != nil {
+			return fmt.Errorf("lỗi khi xóa mã khôi phục: %w", err)
+		}
+
+		return s.mailService.SendSecurityAlert(ctx, account, mailer.SecurityEventMFADisabled)
+	})
+}
+
+func (s *MFAServiceImpl) getAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	account, err := s.accountRepository.GetAccountById(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+// checkCode verifies a TOTP code behind limitGuesses.
+func (s *MFAServiceImpl) checkCode(ctx context.Context, account *models.Account, code string) error {
+	return s.limitGuesses(ctx, account, func() error {
+		return s.verifyTOTP(ctx, account, code)
+	})
+}
+
+// limitGuesses runs check, a check of a TOTP or recovery code, unless the 2FA
+// checks of the account are locked. Wrong codes are counted per account with
+// the login throttle, so opening new login challenges does not give more
+// guesses.
+func (s *MFAServiceImpl) limitGuesses(ctx context.Context, account *models.Account, check func() error) error {
+	accountID := account.ID.String()
+	if err := checkMFALock(ctx, s.redisStore, accountID); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, ErrInvalidMFACode) {
+		if recordErr := recordMFAFailure(ctx, s.redisStore, accountID); recordErr != nil {
+			return recordErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.redisStore.ClearLoginFailures(ctx, mfaAccountKey(accountID)); err != nil {
+		return fmt.Errorf("lỗi khi xóa bộ đếm mã xác thực: %w", err)
+	}
+	return nil
+}
+
+func (s *MFAServiceImpl) verifyTOTP(ctx context.Context, account *models.Account, code string) error {
+	secret, err := utils.DecryptString(config.AppConfig.MFAEncryptionKey, account.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("lỗi khi giải mã khóa xác thực hai lớp: %w", err)
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	fresh, err := s.redisStore.MarkTOTPStepUsed(ctx, account.ID.String(), step, totpStepUsedTTL)
+	if err != nil {
+		return fmt.Errorf("lỗi khi kiểm tra mã xác thực: %w", err)
+	}
+
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *MFAServiceImpl) useRecoveryCode(ctx context.Context, account *models.Account, code string) error {
+	used, err := s.recoveryCodeRepository.Use(ctx, account.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("lỗi khi kiểm tra mã khôi phục: %w", err)
+	}
+
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// recoveryCodeAlphabet leaves out characters that are easy to misread.
+const recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func (s *MFAServiceImpl) generateRecoveryCodes(ctx context.Context, account *models.Account) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		var builder strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				builder.WriteByte('-')
+			}
+
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, fmt.Errorf("lỗi khi tạo mã khôi phục: %w", err)
+			}
+			builder.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+
+		code := builder.String()
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.recoveryCodeRepository.ReplaceForAccount(ctx, account.ID, hashes); err != nil {
+		return nil, fmt.Errorf("lỗi khi lưu mã khôi phục: %w", err)
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -1,16 +1,28 @@
 package services
 
 import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"context"
 	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProfileNotFound = common.NewNotFound("PROFILE_NOT_FOUND", "profile not found")
+	ErrProfileExists   = common.NewConflict("PROFILE_EXISTS", "profile already exists")
 )
 
 type ProfileService interface {
 	GetProfileByID(ctx context.Context, id string) (*models.Profile, error)
-	CreateProfile(ctx context.Context, profileRequest *models.Profile) (*models.Profile, error)
-	UpdateProfile(ctx context.Context, cond string, profileRequest *models.Profile) (*models.Profile, error)
+	CreateProfile(ctx context.Context, userID string, profileRequest *dto.CreateProfileRequest, avatarURL string) (*models.Profile, error)
+	UpdateProfile(ctx context.Context, cond string, profileRequest *dto.UpdateProfileRequest, avatarURL string) (*models.Profile, error)
+	PatchProfile(ctx context.Context, cond string, profileRequest *dto.PatchProfileRequest, avatarURL string) (*models.Profile, error)
+	RemoveAvatar(ctx context.Context, cond string) (*models.Profile, string, error)
+	DeleteProfile(ctx context.Context, cond string) (*models.Profile, error)
 }
 
 type ProfileServiceImpl struct {
@@ -24,25 +36,40 @@ func NewProfileServiceImpl(repo repositories.ProfileRepository) *ProfileServiceI
 }
 
 func(s *ProfileServiceImpl) GetProfileByID(ctx context.Context, id string) (*models.Profile, error){
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrProfileNotFound
+	}
+
 	profile, err := s.repo.GetProfileByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if profile == nil {
-		return nil, fmt.Errorf("profile not found")
+		return nil, ErrProfileNotFound
 	}
 	return profile, nil
 }
 
 
-func(s *ProfileServiceImpl) CreateProfile(ctx context.Context, profileRequest *models.Profile) (*models.Profile, error){
+// CreateProfile creates the profile of the account userID, the account of the
+// request.
+func(s *ProfileServiceImpl) CreateProfile(ctx context.Context, userID string, profileRequest *dto.CreateProfileRequest, avatarURL string) (*models.Profile, error){
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
 	//check if the profile exists 
-	if _, err := s.repo.GetProfileByID(ctx, profileRequest.UserID.String()); err != nil {
+	existingProfile, err := s.repo.GetProfileByID(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
+	if existingProfile != nil {
+		return nil, ErrProfileExists
+	}
 
-	profile, err := s.repo.Create(ctx, profileRequest)
+	profile, err := s.repo.Create(ctx, profileRequest.ToProfile(ownerID, avatarURL))
 	if err != nil{
 		return nil, err
 	}
@@ -53,21 +80,96 @@ func(s *ProfileServiceImpl) CreateProfile(ctx context.Context, profileRequest *m
 func(s *ProfileServiceImpl) UpdateProfile(
 	ctx context.Context, 
 	cond string, 
-	profileRequest *models.Profile,
+	profileRequest *dto.UpdateProfileRequest,
+	avatarURL string,
 	) (*models.Profile, error){
+		if _, err := uuid.Parse(cond); err != nil {
+			return nil, ErrProfileNotFound
+		}
+
 		profile, err := s.repo.GetProfileByID(ctx, cond); 
 		if err != nil {
-			return nil, fmt.Errorf("profile not found")
+			return nil, err
 		}
 
-		profile.FullName 	= profileRequest.FullName
-		profile.DayOfBirth 	= profileRequest.DayOfBirth
-		profile.Gender 		= profileRequest.Gender
-		profile.AvatarURL 	= profileRequest.AvatarURL
+		if profile == nil {
+			return nil, ErrProfileNotFound
+		}
+
+		profileRequest.ApplyTo(profile)
+		// Without a new image the avatar stays as it is
+		if avatarURL != "" {
+			profile.AvatarURL = avatarURL
+		}
 	
 		if err := s.repo.Update(ctx, map[string]interface{}{"user_id":cond}, profile); err != nil {
 			return nil, fmt.Errorf("error updating profile: %w", err)
 		}
 
 		return profile, nil
-}
\ No newline at end of file
+}
+
+// PatchProfile changes the fields set in the request. Without a new image the
+// avatar stays as it is.
+func(s *ProfileServiceImpl) PatchProfile(
+	ctx context.Context,
+	cond string,
+	profileRequest *dto.PatchProfileRequest,
+	avatarURL string,
+	) (*models.Profile, error){
+		profile, err := s.GetProfileByID(ctx, cond)
+		if err != nil {
+			return nil, err
+		}
+
+		profileRequest.ApplyTo(profile)
+		if avatarURL != "" {
+			profile.AvatarURL = avatarURL
+		}
+
+		if err := s.repo.Update(ctx, map[string]interface{}{"user_id":cond}, profile); err != nil {
+			return nil, fmt.Errorf("error updating profile: %w", err)
+		}
+
+		return profile, nil
+}
+
+// RemoveAvatar clears the avatar of the profile and returns the file name it
+// had, for the caller to delete the stored image.
+func(s *ProfileServiceImpl) RemoveAvatar(ctx context.Context, cond string) (*models.Profile, string, error){
+	profile, err := s.GetProfileByID(ctx, cond)
+	if err != nil {
+		return nil, "", err
+	}
+
+	removedURL := profile.AvatarURL
+	if removedURL == "" {
+		return profile, "", nil
+	}
+
+	profile.AvatarURL = ""
+	if err := s.repo.Update(ctx, map[string]interface{}{"user_id":cond}, profile); err != nil {
+		return nil, "", fmt.Errorf("error updating profile: %w", err)
+	}
+
+	return profile, removedURL, nil
+}
+
+// DeleteProfile removes the profile and returns it, for the caller to delete
+// the stored avatar.
+func(s *ProfileServiceImpl) DeleteProfile(ctx context.Context, cond string) (*models.Profile, error){
+	profile, err := s.GetProfileByID(ctx, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.repo.Delete(ctx, cond)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting profile: %w", err)
+	}
+	if !deleted {
+		return nil, ErrProfileNotFound
+	}
+
+	return profile, nil
+}
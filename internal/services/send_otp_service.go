@@ -1,88 +1,203 @@
 package services
 
 import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
 	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
-	"net/smtp"
+	"math"
+	"math/big"
+	"strings"
+	"time"
 )
 
+const (
+	otpTTL         = 10 * time.Minute
+	otpMaxAttempts = 5
 
-// SendOTPService interface defines methods for sending and verifying OTPs.
-type SendOTPService interface {
-	SendOTPAndStore(ctx context.Context, email string) (error)
-	VerifyOTPInRedis(ctx context.Context, email, otp string) (bool, error)
+	// A code can be sent again after otpResendCooldown, at most
+	// otpDailyLimit times per purpose and email in any 24 hours.
+	otpResendCooldown = 60 * time.Second
+	otpDailyWindow    = 24 * time.Hour
+	otpDailyLimit     = 10
+)
+
+var (
+	ErrOTPInvalid         = common.NewValidation("OTP_INVALID", "OTP không chính xác")
+	ErrOTPExpired         = common.NewValidation("OTP_EXPIRED", "OTP không hợp lệ hoặc đã hết hạn")
+	ErrOTPTooManyAttempts = common.NewRateLimited("OTP_TOO_MANY_ATTEMPTS", "nhập sai OTP quá nhiều lần, vui lòng yêu cầu mã mới")
+	ErrOTPRateLimited     = common.NewRateLimited("OTP_RATE_LIMITED", "yêu cầu gửi OTP quá nhanh")
+)
+
+// otpRateLimitError is returned when a code may not be sent yet.
+func otpRateLimitError(retryAfter time.Duration, limitReached bool) *common.AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	message := fmt.Sprintf("vui lòng đợi %d giây trước khi yêu cầu OTP mới", seconds)
+	if limitReached {
+		message = fmt.Sprintf("đã vượt quá số lần gửi OTP trong ngày, vui lòng thử lại sau %d giây", seconds)
+	}
+	return ErrOTPRateLimited.WithMessage(message).WithRetryAfter(retryAfter)
+}
+
+// OTPSendStatus tells the client when it may ask for the next code.
+type OTPSendStatus struct {
+	RetryAfter     time.Duration
+	RemainingToday int
 }
 
-// EmailConfig holds the configuration for sending emails.
-// It includes SMTP host, port, sender email, and password.
-// This struct is used to configure the email sending service.
-// It is initialized with values from the application configuration.
-// The SMTP host and port are used to connect to the email server.
-type EmailConfig struct {
-	SMTPHost    string
-	SMTPPort    string
-	SenderEmail string
-	SenderPass  string
+// PendingOTP is a code whose email is queued but which is not stored in
+// Redis yet.
+type PendingOTP struct {
+	purpose common.OTPPurpose
+	email   string
+	hash    string
 }
 
+// SendOTPService interface defines methods for sending and verifying OTPs.
+type SendOTPService interface {
+	SendOTPAndStore(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) (*OTPSendStatus, error)
+	ReserveOTPSend(ctx context.Context, purpose common.OTPPurpose, email string) (*OTPSendStatus, error)
+	IssueOTP(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) error
+	QueueOTP(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) (*PendingOTP, error)
+	StorePendingOTP(ctx context.Context, pending *PendingOTP) error
+	VerifyOTPInRedis(ctx context.Context, purpose common.OTPPurpose, email, otp string) (bool, error)
+}
 
 type SendOTPServiceImpl struct {
-	emailConfig   EmailConfig
-	redisStore    repositories.RedisStore
+	mailService MailService
+	redisStore  repositories.RedisStore
 }
 
-func NewSendOTPServiceImpl(emailConfig EmailConfig, redisStore repositories.RedisStore) *SendOTPServiceImpl {
+func NewSendOTPServiceImpl(mailService MailService, redisStore repositories.RedisStore) *SendOTPServiceImpl {
 	return &SendOTPServiceImpl{
-		emailConfig: emailConfig,
+		mailService: mailService,
 		redisStore:  redisStore,
 	}
 }
 
-func(s *SendOTPServiceImpl) generateOTP() string {
-	return fmt.Sprintf("%06d", rand.Intn(1000000))
+func(s *SendOTPServiceImpl) generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashOTP keys the hash with OTP_HMAC_KEY and binds it to the purpose and
+// email, so a leaked Redis dump cannot be brute-forced offline.
+func(s *SendOTPServiceImpl) hashOTP(purpose common.OTPPurpose, email, otp string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.OTPHMACKey))
+	mac.Write([]byte(string(purpose) + ":" + strings.ToLower(email) + ":" + otp))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
-func(s *SendOTPServiceImpl) sendOTP(toEmail, otp string) error {
-	subject := "Verifi email address with OTP"
-	body := fmt.Sprintf("Your authentication code is: %s\nPlease use this code within 10 minutes before it expires.", otp)
-	msg := []byte("To: " + toEmail + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"\r\n" + body,
-	)
-
-	auth := smtp.PlainAuth("", config.AppConfig.SenderEmail, config.AppConfig.SenderPass, s.emailConfig.SMTPHost)
-	addr := config.AppConfig.SMTPHost + ":" + config.AppConfig.SMTPPort
-	err := smtp.SendMail(addr, auth, s.emailConfig.SenderEmail, []string{toEmail}, msg)
+// SendOTPAndStore issues a new code for the purpose, replacing the previous
+// one, unless the resend cooldown or the daily limit says otherwise. The email
+// is written in the given locale.
+func(s *SendOTPServiceImpl) SendOTPAndStore(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) (*OTPSendStatus, error) {
+	status, err := s.ReserveOTPSend(ctx, purpose, email)
 	if err != nil {
-		return fmt.Errorf("gửi email thất bại: %w", err)
+		return nil, err
 	}
-	return nil
+
+	if err := s.IssueOTP(ctx, purpose, email, locale); err != nil {
+		return nil, err
+	}
+
+	return status, nil
 }
 
-func(s *SendOTPServiceImpl) SendOTPAndStore(ctx context.Context, email string) (error) {
-	otp := s.generateOTP()
-	if err := s.redisStore.StoreOTP(ctx, email, otp); err != nil {
-		return fmt.Errorf("lưu OTP vào Redis thất bại: %w", err)
+// IssueOTP replaces the code of the purpose and email and sends it, with no
+// check of the cooldown and the daily limit: the caller reserved the send.
+func(s *SendOTPServiceImpl) IssueOTP(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) error {
+	pending, err := s.QueueOTP(ctx, purpose, email, locale)
+	if err != nil {
+		return err
 	}
 
-	if err := s.sendOTP(email, otp); err != nil {
-		return fmt.Errorf("gửi OTP qua email thất bại: %w", err)
+	return s.storeOTP(ctx, pending)
+}
+
+// QueueOTP generates a code and queues its email without writing to Redis, so
+// it can run in a transaction that may still roll back. The code cannot be
+// verified until StorePendingOTP saves it.
+func(s *SendOTPServiceImpl) QueueOTP(ctx context.Context, purpose common.OTPPurpose, email string, locale mailer.Locale) (*PendingOTP, error) {
+	otp, err := s.generateOTP()
+	if err != nil {
+		return nil, fmt.Errorf("tạo OTP thất bại: %w", err)
 	}
 
+	if err := s.mailService.SendOTP(ctx, locale, email, purpose, otp); err != nil {
+		return nil, fmt.Errorf("gửi OTP qua email thất bại: %w", err)
+	}
+
+	return &PendingOTP{
+		purpose: purpose,
+		email:   email,
+		hash:    s.hashOTP(purpose, email, otp),
+	}, nil
+}
+
+// StorePendingOTP saves a queued code once its email is committed and counts
+// it against the cooldown and the daily limit. The email is already on its
+// way, so a send over the limit is still stored.
+func(s *SendOTPServiceImpl) StorePendingOTP(ctx context.Context, pending *PendingOTP) error {
+	if _, err := s.redisStore.ReserveOTPSend(ctx, pending.purpose, pending.email, otpResendCooldown, otpDailyWindow, otpDailyLimit); err != nil {
+		return fmt.Errorf("kiểm tra giới hạn gửi OTP thất bại: %w", err)
+	}
+
+	return s.storeOTP(ctx, pending)
+}
+
+func(s *SendOTPServiceImpl) storeOTP(ctx context.Context, pending *PendingOTP) error {
+	if err := s.redisStore.StoreOTP(ctx, pending.purpose, pending.email, pending.hash, otpTTL); err != nil {
+		return fmt.Errorf("lưu OTP vào Redis thất bại: %w", err)
+	}
 	return nil
 }
 
-func(s *SendOTPServiceImpl) VerifyOTPInRedis(ctx context.Context, email, otp string) (bool, error) {
-	isValid, err := s.redisStore.VerifyOTP(ctx, email, otp)
+// ReserveOTPSend counts a send against the resend cooldown and the daily
+// limit of the purpose and email, without sending anything. Flows that must
+// not tell whether an email has an account reserve for every email and only
+// send for the ones that do.
+func(s *SendOTPServiceImpl) ReserveOTPSend(ctx context.Context, purpose common.OTPPurpose, email string) (*OTPSendStatus, error) {
+	quota, err := s.redisStore.ReserveOTPSend(ctx, purpose, email, otpResendCooldown, otpDailyWindow, otpDailyLimit)
 	if err != nil {
-		return false, fmt.Errorf("xác thực OTP thất bại: %w", err)
+		return nil, fmt.Errorf("kiểm tra giới hạn gửi OTP thất bại: %w", err)
 	}
-	if !isValid {
-		return false, fmt.Errorf("OTP không hợp lệ hoặc đã hết hạn")
+
+	if !quota.Allowed {
+		return nil, otpRateLimitError(quota.RetryAfter, quota.LimitReached)
 	}
-	return true, nil
+
+	return &OTPSendStatus{
+		RetryAfter:     otpResendCooldown,
+		RemainingToday: quota.Remaining,
+	}, nil
 }
 
+// VerifyOTPInRedis consumes the code on success. Wrong guesses are counted and
+// the code is discarded after otpMaxAttempts of them.
+func(s *SendOTPServiceImpl) VerifyOTPInRedis(ctx context.Context, purpose common.OTPPurpose, email, otp string) (bool, error) {
+	status, err := s.redisStore.VerifyOTP(ctx, purpose, email, s.hashOTP(purpose, email, otp), otpMaxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("xác thực OTP thất bại: %w", err)
+	}
+
+	switch status {
+	case repositories.OTPValid:
+		return true, nil
+	case repositories.OTPExpired:
+		return false, ErrOTPExpired
+	case repositories.OTPTooManyAttempts:
+		return false, ErrOTPTooManyAttempts
+	default:
+		return false, ErrOTPInvalid
+	}
+}
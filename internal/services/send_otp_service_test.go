@@ -0,0 +1,149 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/config"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingMailService keeps the last OTP sent instead of queueing emails.
+type recordingMailService struct {
+	sent    int
+	lastOTP string
+}
+
+func (m *recordingMailService) SendOTP(ctx context.Context, locale mailer.Locale, email string, purpose common.OTPPurpose, otp string) error {
+	m.sent++
+	m.lastOTP = otp
+	return nil
+}
+
+func (m *recordingMailService) SendWelcome(ctx context.Context, account *models.Account) error {
+	return nil
+}
+
+func (m *recordingMailService) SendSecurityAlert(ctx context.Context, account *models.Account, event mailer.SecurityEvent) error {
+	return nil
+}
+
+func (m *recordingMailService) SendSetPassword(ctx context.Context, account *models.Account) error {
+	return nil
+}
+
+func newTestSendOTPService(t *testing.T) (*SendOTPServiceImpl, *recordingMailService) {
+	t.Helper()
+	config.AppConfig = &config.Config{OTPHMACKey: "0123456789abcdef0123456789abcdef"}
+
+	mailService := &recordingMailService{}
+	return NewSendOTPServiceImpl(mailService, repositories.NewMemoryStore()), mailService
+}
+
+func TestSendOTPCooldown(t *testing.T) {
+	ctx := context.Background()
+	service, mailService := newTestSendOTPService(t)
+
+	status, err := service.SendOTPAndStore(ctx, common.OTPPurposeVerifyEmail, "user@example.com", mailer.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.RetryAfter != otpResendCooldown || status.RemainingToday != otpDailyLimit-1 {
+		t.Fatalf("status = %+v, want the cooldown and %d sends left", status, otpDailyLimit-1)
+	}
+
+	_, err = service.SendOTPAndStore(ctx, common.OTPPurposeVerifyEmail, "user@example.com", mailer.DefaultLocale)
+	if !errors.Is(err, ErrOTPRateLimited) {
+		t.Fatalf("send within the cooldown = %v, want ErrOTPRateLimited", err)
+	}
+	if mailService.sent != 1 {
+		t.Fatalf("emails sent = %d, want 1", mailService.sent)
+	}
+
+	// The cooldown is per purpose
+	if _, err := service.SendOTPAndStore(ctx, common.OTPPurposeResetPassword, "user@example.com", mailer.DefaultLocale); err != nil {
+		t.Fatalf("send for another purpose = %v", err)
+	}
+}
+
+func TestSendOTPRotation(t *testing.T) {
+	ctx := context.Background()
+	service, mailService := newTestSendOTPService(t)
+	purpose, email := common.OTPPurposeResetPassword, "user@example.com"
+
+	if err := service.IssueOTP(ctx, purpose, email, mailer.DefaultLocale); err != nil {
+		t.Fatal(err)
+	}
+	firstOTP := mailService.lastOTP
+
+	if err := service.IssueOTP(ctx, purpose, email, mailer.DefaultLocale); err != nil {
+		t.Fatal(err)
+	}
+	secondOTP := mailService.lastOTP
+
+	// The first code only stays valid when both happen to be the same
+	if firstOTP != secondOTP {
+		if _, err := service.VerifyOTPInRedis(ctx, purpose, email, firstOTP); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("replaced code = %v, want ErrOTPInvalid", err)
+		}
+	}
+
+	if ok, err := service.VerifyOTPInRedis(ctx, purpose, email, secondOTP); !ok || err != nil {
+		t.Fatalf("new code = %t, %v, want valid", ok, err)
+	}
+	if _, err := service.VerifyOTPInRedis(ctx, purpose, email, secondOTP); !errors.Is(err, ErrOTPExpired) {
+		t.Fatalf("used code = %v, want ErrOTPExpired", err)
+	}
+}
+
+func TestVerifyOTPTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	service, mailService := newTestSendOTPService(t)
+	purpose, email := common.OTPPurposeVerifyEmail, "user@example.com"
+
+	if err := service.IssueOTP(ctx, purpose, email, mailer.DefaultLocale); err != nil {
+		t.Fatal(err)
+	}
+
+	wrongOTP := "000000"
+	if mailService.lastOTP == wrongOTP {
+		wrongOTP = "111111"
+	}
+	for i := 1; i < otpMaxAttempts; i++ {
+		if _, err := service.VerifyOTPInRedis(ctx, purpose, email, wrongOTP); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("wrong guess %d = %v, want ErrOTPInvalid", i, err)
+		}
+	}
+	if _, err := service.VerifyOTPInRedis(ctx, purpose, email, wrongOTP); !errors.Is(err, ErrOTPTooManyAttempts) {
+		t.Fatalf("last wrong guess = %v, want ErrOTPTooManyAttempts", err)
+	}
+	if _, err := service.VerifyOTPInRedis(ctx, purpose, email, mailService.lastOTP); !errors.Is(err, ErrOTPTooManyAttempts) {
+		t.Fatalf("right code after the limit = %v, want ErrOTPTooManyAttempts", err)
+	}
+}
+
+func TestStorePendingOTPOverLimit(t *testing.T) {
+	ctx := context.Background()
+	service, mailService := newTestSendOTPService(t)
+	purpose, email := common.OTPPurposeVerifyEmail, "user@example.com"
+
+	if _, err := service.ReserveOTPSend(ctx, purpose, email); err != nil {
+		t.Fatal(err)
+	}
+
+	// The email of a pending code is already queued, so the cooldown does not
+	// stop the code from being stored
+	pending, err := service.QueueOTP(ctx, purpose, email, mailer.DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.StorePendingOTP(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := service.VerifyOTPInRedis(ctx, purpose, email, mailService.lastOTP); !ok || err != nil {
+		t.Fatalf("pending code = %t, %v, want valid", ok, err)
+	}
+}
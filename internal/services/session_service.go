@@ -0,0 +1,100 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+var ErrSessionNotFound = common.NewNotFound("SESSION_NOT_FOUND", "không tìm thấy phiên đăng nhập")
+
+// ClientInfo describes the device a request comes from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionService interface {
+	ListSessions(ctx context.Context, accountID, currentSessionID string) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, accountID, sessionID string) error
+	RevokeUserSession(ctx context.Context, actor Actor, accountID, sessionID string) error
+}
+
+type SessionServiceImpl struct {
+	sessionRepository repositories.SessionRepository
+	accountRepository repositories.AccountRepository
+	redisStore        repositories.RedisStore
+}
+
+func NewSessionServiceImpl(
+	sessionRepo repositories.SessionRepository,
+	accountRepo repositories.AccountRepository,
+	redis repositories.RedisStore,
+) *SessionServiceImpl {
+	return &SessionServiceImpl{
+		sessionRepository: sessionRepo,
+		accountRepository: accountRepo,
+		redisStore:        redis,
+	}
+}
+
+// ListSessions returns the active sessions of the account and flags the one
+// with currentSessionID, if any.
+func (s *SessionServiceImpl) ListSessions(ctx context.Context, accountID, currentSessionID string) ([]*models.Session, error) {
+	sessions, err := s.sessionRepository.GetActiveListByAccountId(ctx, accountID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy danh sách phiên đăng nhập: %w", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID.String() == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends a session of the account. Its refresh token family is
+// dropped, so neither the refresh token nor the access tokens issued with it
+// work any more.
+func (s *SessionServiceImpl) RevokeSession(ctx context.Context, accountID, sessionID string) error {
+	session, err := s.sessionRepository.GetById(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("lỗi khi lấy phiên đăng nhập: %w", err)
+	}
+
+	if session == nil || session.AccountID.String() != accountID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionRepository.Revoke(ctx, sessionID, time.Now()); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi phiên đăng nhập: %w", err)
+	}
+
+	if err := s.redisStore.RevokeRefreshFamily(ctx, sessionID); err != nil {
+		return fmt.Errorf("lỗi khi thu hồi refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSession is RevokeSession on the account of another user, which is
+// refused when its role outranks the one of actor.
+func (s *SessionServiceImpl) RevokeUserSession(ctx context.Context, actor Actor, accountID, sessionID string) error {
+	account, err := s.accountRepository.GetAccountById(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return ErrSessionNotFound
+	}
+
+	if err := checkRoleRank(actor, account.Role); err != nil {
+		return err
+	}
+
+	return s.RevokeSession(ctx, accountID, sessionID)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportColumns is the header row of the export, in the order of
+// exportRecord.
+var exportColumns = []string{
+	"id",
+	"email",
+	"role",
+	"status",
+	"is_verified",
+	"lock_reason",
+	"locked_until",
+	"totp_enabled",
+	"locale",
+	"created_at",
+	"full_name",
+	"date_of_birth",
+	"gender",
+}
+
+const exportSheetName = "Users"
+
+// xlsxExportMaxRows caps the accounts of an XLSX export. excelize builds the
+// whole zip in memory when the workbook is written, so a larger export has to
+// use CSV, which is streamed row by row.
+const xlsxExportMaxRows = 50000
+
+var ErrExportTooLarge = common.NewValidation("EXPORT_TOO_LARGE", "danh sách quá lớn để xuất dạng xlsx, vui lòng thu hẹp bộ lọc hoặc xuất dạng csv")
+
+type UserExportService interface {
+	ExportUsers(ctx context.Context, filter *common.AccountFilter, format string, w io.Writer) error
+}
+
+type UserExportServiceImpl struct {
+	accountRepository repositories.AccountRepository
+}
+
+func NewUserExportServiceImpl(accountRepo repositories.AccountRepository) *UserExportServiceImpl {
+	return &UserExportServiceImpl{accountRepository: accountRepo}
+}
+
+// exportWriter writes the rows of an export in one file format.
+type exportWriter interface {
+	WriteRow(values []string) error
+	// Flush writes what is still buffered. It must be called once every row
+	// is written, and only then.
+	Flush() error
+}
+
+// ExportUsers writes the accounts matching filter with their profile to w as
+// CSV or XLSX. Accounts are read and written one by one. Nothing is written
+// to w when the accounts cannot be read at all, nor for an XLSX export of
+// more than xlsxExportMaxRows accounts.
+func (s *UserExportServiceImpl) ExportUsers(ctx context.Context, filter *common.AccountFilter, format string, w io.Writer) error {
+	var writer exportWriter
+	switch format {
+	case common.ExportFormatXLSX:
+		xlsxWriter, err := newXLSXExportWriter(w)
+		if err != nil {
+			return fmt.Errorf("lỗi khi tạo tệp xlsx: %w", err)
+		}
+		// Removes the temporary files of the stream writer
+		defer xlsxWriter.file.Close()
+		writer = xlsxWriter
+	default:
+		writer = newCSVExportWriter(w)
+	}
+
+	headerWritten := false
+	now := time.Now()
+	err := s.accountRepository.ExportAccounts(ctx, filter, func(row *models.AccountExport) error {
+		// The header waits for the first row, so a query that fails
+		// right away leaves w untouched
+		if !headerWritten {
+			if err := writer.WriteRow(exportColumns); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+		return writer.WriteRow(exportRecord(row, now))
+	})
+	if err != nil {
+		return fmt.Errorf("lỗi khi xuất danh sách tài khoản: %w", err)
+	}
+
+	if !headerWritten {
+		if err := writer.WriteRow(exportColumns); err != nil {
+			return fmt.Errorf("lỗi khi xuất danh sách tài khoản: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("lỗi khi xuất danh sách tài khoản: %w", err)
+	}
+	return nil
+}
+
+func exportRecord(row *models.AccountExport, now time.Time) []string {
+	status := common.AccountStatusActive
+	if row.IsLocked(now) {
+		status = common.AccountStatusLocked
+	}
+
+	var fullName, dateOfBirth, gender string
+	if row.FullName != nil {
+		fullName = *row.FullName
+	}
+	if row.DayOfBirth != nil {
+		dateOfBirth = row.DayOfBirth.Format(time.DateOnly)
+	}
+	if row.Gender != nil {
+		gender = "female"
+		if *row.Gender {
+			gender = "male"
+		}
+	}
+
+	return []string{
+		row.ID.String(),
+		row.Email,
+		row.Role,
+		status,
+		strconv.FormatBool(row.IsVerified),
+		formatOptionalString(row.LockReason),
+		formatOptionalTime(row.LockedUntil),
+		strconv.FormatBool(row.TOTPEnabled),
+		row.Locale,
+		row.CreatedAt.Format(time.RFC3339),
+		fullName,
+		dateOfBirth,
+		gender,
+	}
+}
+
+func formatOptionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
+
+type csvExportWriter struct {
+	w         io.Writer
+	csvWriter *csv.Writer
+	bomSent   bool
+}
+
+func newCSVExportWriter(w io.Writer) *csvExportWriter {
+	return &csvExportWriter{w: w, csvWriter: csv.NewWriter(w)}
+}
+
+func (c *csvExportWriter) WriteRow(values []string) error {
+	// The byte order mark makes spreadsheets read the file as UTF-8
+	if !c.bomSent {
+		if _, err := io.WriteString(c.w, "\ufeff"); err != nil {
+			return err
+		}
+		c.bomSent = true
+	}
+
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = escapeCSVFormula(value)
+	}
+	return c.csvWriter.Write(record)
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.csvWriter.Flush()
+	return c.csvWriter.Error()
+}
+
+// escapeCSVFormula keeps a spreadsheet from running a value typed by a user,
+// such as a full name, as a formula.
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// xlsxExportWriter writes the rows with the stream writer of excelize, which
+// keeps them in a temporary file. Flush still assembles the whole workbook in
+// memory before writing it to w, hence xlsxExportMaxRows. The file must be
+// closed after.
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", exportSheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(exportSheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxExportWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxExportWriter) WriteRow(values []string) error {
+	// The first row is the header
+	if x.row > xlsxExportMaxRows {
+		return ErrExportTooLarge.WithDetails(map[string]interface{}{
+			"max_rows": xlsxExportMaxRows,
+		})
+	}
+
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+
+	record := make([]interface{}, len(values))
+	for i, value := range values {
+		record[i] = value
+	}
+	return x.stream.SetRow(cell, record)
+}
+
+func (x *xlsxExportWriter) Flush() error {
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
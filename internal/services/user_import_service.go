@@ -0,0 +1,391 @@
+package services
+
+import (
+	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
+	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxImportFileSize and MaxImportRows bound the work of one import.
+	MaxImportFileSize = 5 << 20
+	MaxImportRows     = 5000
+
+	// importBatchSize is the number of accounts created in one transaction.
+	importBatchSize = 200
+)
+
+var (
+	ErrInvalidImportFile = common.NewValidation("INVALID_IMPORT_FILE", "tệp CSV không hợp lệ")
+	ErrImportTooManyRows = common.NewValidation("IMPORT_TOO_MANY_ROWS", fmt.Sprintf("tệp CSV chỉ được có tối đa %d dòng", MaxImportRows))
+)
+
+// importRequiredColumns must be named in the header row of the CSV, in any
+// order. The role column is optional and defaults to user.
+var importRequiredColumns = []string{"email", "full_name", "date_of_birth", "gender"}
+
+// importGenders maps the gender column to Profile.Gender, true for male.
+var importGenders = map[string]bool{
+	"male":   true,
+	"nam":    true,
+	"female": false,
+	"nu":     false,
+	"nữ":     false,
+}
+
+// importRowMessages are the row errors that are not validation rules.
+var importRowMessages = map[string]map[string]string{
+	"duplicate": {
+		common.LocaleVI: "email trùng với dòng %d",
+		common.LocaleEN: "email repeats row %d",
+	},
+	"exists": {
+		common.LocaleVI: "email đã được dùng cho một tài khoản khác",
+		common.LocaleEN: "email is already used by another account",
+	},
+	"role_not_assignable": {
+		common.LocaleVI: "không thể tạo tài khoản có vai trò cao hơn vai trò của bạn",
+		common.LocaleEN: "accounts cannot be given a role above your own",
+	},
+	"create_failed": {
+		common.LocaleVI: "không thể tạo tài khoản, hãy nhập lại dòng này",
+		common.LocaleEN: "the account could not be created, import this row again",
+	},
+}
+
+type UserImportService interface {
+	ImportUsers(ctx context.Context, actor Actor, file io.Reader, request *common.RequestImportUsers, locale string) (*UserImportResult, error)
+}
+
+// UserImportRowError lists what is wrong with a row of the CSV. Row is the
+// line of the file, the header being line 1.
+type UserImportRowError struct {
+	Row     int                 `json:"row"`
+	Email   string              `json:"email,omitempty"`
+	Message string              `json:"message,omitempty"`
+	Errors  []common.FieldError `json:"errors,omitempty"`
+}
+
+// UserImportResult sums up an import. A dry run stops after the checks, so
+// ValidRows is what a real import of the same file would create.
+type UserImportResult struct {
+	DryRun    bool                 `json:"dry_run"`
+	TotalRows int                  `json:"total_rows"`
+	ValidRows int                  `json:"valid_rows"`
+	Created   int                  `json:"created"`
+	Failed    int                  `json:"failed"`
+	Errors    []UserImportRowError `json:"errors"`
+}
+
+// importUserRow is a row of the CSV, validated with the rules of the service.
+type importUserRow struct {
+	line        int
+	Email       string `json:"email" validate:"required,email,max=255"`
+	Role        string `json:"role" validate:"oneof=admin user expert support"`
+	FullName    string `json:"full_name" validate:"required,max=100"`
+	DateOfBirth string `json:"date_of_birth" validate:"required,datetime=2006-01-02,past_date"`
+	Gender      string `json:"gender" validate:"required,oneof=male female nam nu nữ"`
+}
+
+type UserImportServiceImpl struct {
+	accountRepository repositories.AccountRepository
+	profileRepository repositories.ProfileRepository
+	mailService       MailService
+	txManager         repositories.TxManager
+}
+
+func NewUserImportServiceImpl(
+	accountRepo repositories.AccountRepository,
+	profileRepo repositories.ProfileRepository,
+	mailService MailService,
+	txManager repositories.TxManager,
+) *UserImportServiceImpl {
+	return &UserImportServiceImpl{
+		accountRepository: accountRepo,
+		profileRepository: profileRepo,
+		mailService:       mailService,
+		txManager:         txManager,
+	}
+}
+
+// ImportUsers creates an account and its profile for every valid row of the
+// CSV and reports the others with their errors, in the language of locale.
+// Rows are created in batches, each in its own transaction, so a failed batch
+// only fails its own rows. A row cannot give a role that outranks the one of
+// actor.
+//
+// Imported accounts are verified and share the hash of one random password
+// that is thrown away: hashing one per row would take minutes on a large
+// file. Their owners set a password through forgot-password, which the
+// set_password email explains.
+func (s *UserImportServiceImpl) ImportUsers(ctx context.Context, actor Actor, file io.Reader, request *common.RequestImportUsers, locale string) (*UserImportResult, error) {
+	locale = common.ParseValidationLocale(locale)
+	result := &UserImportResult{DryRun: request.DryRun, Errors: []UserImportRowError{}}
+
+	rows, err := readImportRows(file, actor, locale, result)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.dropExistingEmails(ctx, rows, locale, result)
+	if err != nil {
+		return nil, err
+	}
+	result.ValidRows = len(rows)
+
+	if !request.DryRun && len(rows) > 0 {
+		password, err := utils.GenerateRandomToken(24)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi khi tạo mật khẩu: %w", err)
+		}
+		passwordHash, err := utils.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi khi mã hóa mật khẩu: %w", err)
+		}
+
+		for start := 0; start < len(rows); start += importBatchSize {
+			batch := rows[start:min(start+importBatchSize, len(rows))]
+			if err := s.createBatch(ctx, batch, passwordHash, request.Notify); err != nil {
+				log.Printf("Import users from row %d to %d failed: %v", batch[0].line, batch[len(batch)-1].line, err)
+				for _, row := range batch {
+					result.addError(UserImportRowError{
+						Row:     row.line,
+						Email:   row.Email,
+						Message: importRowMessages["create_failed"][locale],
+					})
+				}
+				continue
+			}
+			result.Created += len(batch)
+		}
+	}
+
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	return result, nil
+}
+
+// readImportRows returns the valid rows of the CSV, without the rows that
+// repeat the email of an earlier one, and records the errors of the others.
+func readImportRows(file io.Reader, actor Actor, locale string, result *UserImportResult) ([]*importUserRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrInvalidImportFile.WithMessage("tệp CSV trống")
+	}
+	if err != nil {
+		return nil, ErrInvalidImportFile.Wrap(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save UTF-8 with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrInvalidImportFile.WithMessage(fmt.Sprintf("tệp CSV thiếu cột %s", name))
+		}
+	}
+
+	var rows []*importUserRow
+	firstLines := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, ErrInvalidImportFile.
+					WithMessage(fmt.Sprintf("không đọc được dòng %d của tệp CSV", parseErr.StartLine)).
+					Wrap(err)
+			}
+			return nil, ErrInvalidImportFile.Wrap(err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		result.TotalRows++
+		if result.TotalRows > MaxImportRows {
+			return nil, ErrImportTooManyRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		value := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := &importUserRow{
+			line:        line,
+			Email:       common.NormalizeEmail(value("email")),
+			Role:        strings.ToLower(value("role")),
+			FullName:    value("full_name"),
+			DateOfBirth: value("date_of_birth"),
+			Gender:      strings.ToLower(value("gender")),
+		}
+		if row.Role == "" {
+			row.Role = models.RoleUser
+		}
+
+		if err := common.ValidateRequestLocale(row, locale); err != nil {
+			var appErr *common.AppError
+			if !errors.As(err, &appErr) {
+				return nil, err
+			}
+			result.addError(UserImportRowError{Row: line, Email: row.Email, Errors: appErr.FieldErrors})
+			continue
+		}
+
+		if checkRoleRank(actor, row.Role) != nil {
+			result.addError(UserImportRowError{
+				Row:     line,
+				Email:   row.Email,
+				Message: importRowMessages["role_not_assignable"][locale],
+			})
+			continue
+		}
+
+		key := strings.ToLower(row.Email)
+		if firstLine, ok := firstLines[key]; ok {
+			result.addError(UserImportRowError{
+				Row:     line,
+				Email:   row.Email,
+				Message: fmt.Sprintf(importRowMessages["duplicate"][locale], firstLine),
+			})
+			continue
+		}
+		firstLines[key] = line
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// dropExistingEmails returns the rows whose email no account has yet and
+// records the others as errors.
+func (s *UserImportServiceImpl) dropExistingEmails(ctx context.Context, rows []*importUserRow, locale string, result *UserImportResult) ([]*importUserRow, error) {
+	existing := make(map[string]bool)
+	for start := 0; start < len(rows); start += importBatchSize {
+		batch := rows[start:min(start+importBatchSize, len(rows))]
+		emails := make([]string, 0, len(batch))
+		for _, row := range batch {
+			emails = append(emails, strings.ToLower(row.Email))
+		}
+
+		found, err := s.accountRepository.GetExistingEmails(ctx, emails)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi khi kiểm tra tài khoản: %w", err)
+		}
+		for _, email := range found {
+			existing[email] = true
+		}
+	}
+
+	kept := rows[:0]
+	for _, row := range rows {
+		if existing[strings.ToLower(row.Email)] {
+			result.addError(UserImportRowError{
+				Row:     row.line,
+				Email:   row.Email,
+				Message: importRowMessages["exists"][locale],
+			})
+			continue
+		}
+		kept = append(kept, row)
+	}
+
+	return kept, nil
+}
+
+// createBatch creates the accounts and profiles of the rows and queues their
+// emails in one transaction.
+func (s *UserImportServiceImpl) createBatch(ctx context.Context, rows []*importUserRow, passwordHash, notify string) error {
+	accounts := make([]*models.Account, 0, len(rows))
+	profiles := make([]*models.Profile, 0, len(rows))
+	for _, row := range rows {
+		dateOfBirth, err := time.Parse(time.DateOnly, row.DateOfBirth)
+		if err != nil {
+			return err
+		}
+
+		account := &models.Account{
+			ID:            uuid.New(),
+			Email:         row.Email,
+			Password:      passwordHash,
+			Role:          row.Role,
+			IsVerified:    true,
+			AccountStatus: true,
+			Locale:        string(mailer.DefaultLocale),
+		}
+		accounts = append(accounts, account)
+		profiles = append(profiles, &models.Profile{
+			UserID:     account.ID,
+			FullName:   row.FullName,
+			DayOfBirth: &dateOfBirth,
+			Gender:     importGenders[row.Gender],
+		})
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.CreateBatch(ctx, accounts); err != nil {
+			return fmt.Errorf("lỗi khi tạo tài khoản: %w", err)
+		}
+		if err := s.profileRepository.CreateBatch(ctx, profiles); err != nil {
+			return fmt.Errorf("lỗi khi tạo hồ sơ: %w", err)
+		}
+
+		for _, account := range accounts {
+			var err error
+			switch notify {
+			case common.ImportNotifyWelcome:
+				err = s.mailService.SendWelcome(ctx, account)
+			case common.ImportNotifySetPassword:
+				err = s.mailService.SendSetPassword(ctx, account)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *UserImportResult) addError(rowError UserImportRowError) {
+	r.Errors = append(r.Errors, rowError)
+	r.Failed++
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
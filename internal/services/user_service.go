@@ -2,55 +2,82 @@ package services
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/common"
+	"DH52111659-api-quan-ly-suc-khoe/internal/dto"
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidLockedUntil = common.NewValidation("INVALID_LOCKED_UNTIL", "thời hạn khóa phải ở tương lai")
+	ErrUnknownRole        = common.NewValidation("UNKNOWN_ROLE", "vai trò không tồn tại")
 )
 
 type UserService interface {
-	CreateAccount(ctx context.Context, account *models.AccountCreate) (*models.Account, error)
-	ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestAuth) error
-	GetListAccounts(ctx context.Context, paging *common.Paging, cond map[string]interface{}) ([]*models.Account, error)
+	CreateAccount(ctx context.Context, actor Actor, createRequest *dto.CreateAccountRequest) (*models.Account, error)
+	ResetPassword(ctx context.Context, actor Actor, resetPasswordRequest *common.RequestAuth) error
+	GetListAccounts(ctx context.Context, paging *common.Paging, filter *common.AccountFilter) ([]*models.Account, error)
 	GetAccountById(ctx context.Context, id string) (*models.Account, error)
-	LockAccount(ctx context.Context, id string) error
-	UnlockAccount(ctx context.Context, id string) error
+	LockAccount(ctx context.Context, id string, actor Actor, lockRequest *common.RequestLockAccount) error
+	UnlockAccount(ctx context.Context, id string, actor Actor) error
+	GetAccountStatusHistory(ctx context.Context, id string) ([]*models.AccountStatusHistory, error)
+	ClearLoginLockout(ctx context.Context, id string, actor Actor) error
 }
 
 type UserServiceImpl struct {
 	accountRepository repositories.AccountRepository
+	historyRepository repositories.AccountStatusHistoryRepository
+	roleRepository    repositories.RoleRepository
+	redisStore        repositories.RedisStore
+	txManager         repositories.TxManager
 }
 
-func NewUserServiceImpl(accountRepo repositories.AccountRepository) *UserServiceImpl {
-	return &UserServiceImpl{accountRepository: accountRepo}
+func NewUserServiceImpl(
+	accountRepo repositories.AccountRepository,
+	historyRepo repositories.AccountStatusHistoryRepository,
+	roleRepo repositories.RoleRepository,
+	redis repositories.RedisStore,
+	txManager repositories.TxManager,
+) *UserServiceImpl {
+	return &UserServiceImpl{
+		accountRepository: accountRepo,
+		historyRepository: historyRepo,
+		roleRepository:    roleRepo,
+		redisStore:        redis,
+		txManager:         txManager,
+	}
 }
 
-func(s *UserServiceImpl) CreateAccount(ctx context.Context, accountRequest *models.AccountCreate) (*models.Account, error){
+// CreateAccount creates a verified account with the role of the request,
+// which cannot outrank the role of actor.
+func(s *UserServiceImpl) CreateAccount(ctx context.Context, actor Actor, createRequest *dto.CreateAccountRequest) (*models.Account, error){
+	account := createRequest.ToAccount()
+
+	if err := s.checkAssignableRole(ctx, actor, account.Role); err != nil {
+		return nil, err
+	}
+
 	// Check if the account already exists
-	existsAccount, err := s.accountRepository.GetByEmail(ctx, accountRequest.Email)
+	existsAccount, err := s.accountRepository.GetByEmail(ctx, account.Email)
 	if err != nil {
 		return nil, fmt.Errorf("lỗi khi kiểm tra tài khoản: %w", err)
 	}
 
 	if existsAccount != nil {
-		return nil, fmt.Errorf("tài khoản đã tồn tại")
+		return nil, ErrAccountExists
 	}
 
 	// Hash the password before storing
-	hashedPassword, err := utils.HashPassword(accountRequest.Password)
+	hashedPassword, err := utils.HashPassword(account.Password)
 	if err != nil {
 		return nil, fmt.Errorf("lỗi khi mã hóa mật khẩu: %w", err)
 	}
-
-	
-	account := &models.Account{
-		Email:    accountRequest.Email,
-		Password: hashedPassword,
-		Role:     "user",
-		IsVerified: true,
-		AccountStatus: true,
-	}
+	account.Password = hashedPassword
 
 	if err := s.accountRepository.Create(ctx, account); err != nil {
 		return nil, fmt.Errorf("lỗi khi tạo tài khoản: %w", err)
@@ -59,14 +86,18 @@ func(s *UserServiceImpl) CreateAccount(ctx context.Context, accountRequest *mode
 	return account, nil
 }
 
-func(s *UserServiceImpl) ResetPassword(ctx context.Context, resetPasswordRequest *common.RequestAuth) error{
+func(s *UserServiceImpl) ResetPassword(ctx context.Context, actor Actor, resetPasswordRequest *common.RequestAuth) error{
 	account, err := s.accountRepository.GetByEmail(ctx, resetPasswordRequest.Email)
 	if err != nil {
 		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 
 	if account == nil {
-		return fmt.Errorf("tài khoản không tồn tại")
+		return ErrAccountNotFound
+	}
+
+	if err := checkRoleRank(actor, account.Role); err != nil {
+		return err
 	}
 
 	// Hash the new password
@@ -86,12 +117,19 @@ func(s *UserServiceImpl) ResetPassword(ctx context.Context, resetPasswordRequest
 	return nil
 }
 
-func(s *UserServiceImpl) GetListAccounts(ctx context.Context, paging *common.Paging, cond map[string]interface{}) ([]*models.Account, error){
+// accountSortFields are the columns the admin user list can be sorted by.
+var accountSortFields = []string{"created_at", "email", "role"}
+
+func(s *UserServiceImpl) GetListAccounts(ctx context.Context, paging *common.Paging, filter *common.AccountFilter) ([]*models.Account, error){
 	paging.ProcessPaging()
+	if err := paging.ProcessSort(accountSortFields, "created_at"); err != nil {
+		return nil, err
+	}
+
 	accounts, err := s.accountRepository.GetListAccount(
 		ctx, 
 		paging,
-		cond,
+		filter,
 	)
 
 	if err != nil {
@@ -108,49 +146,186 @@ func(s *UserServiceImpl) GetAccountById(ctx context.Context, id string) (*models
 	}
 
 	if account == nil {
-		return nil, fmt.Errorf("tài khoản không tồn tại")
+		return nil, ErrAccountNotFound
 	}
 
 	return account, nil
 }
 
-func(s *UserServiceImpl) LockAccount(ctx context.Context, id string) error {
+// LockAccount locks the account, optionally with a reason and an end time for
+// temporary suspensions. Tokens already issued stop working as soon as the
+// cached account state is dropped. Accounts whose role outranks the one of
+// actor cannot be locked.
+func(s *UserServiceImpl) LockAccount(ctx context.Context, id string, actor Actor, lockRequest *common.RequestLockAccount) error {
 	account, err := s.accountRepository.GetAccountById(ctx, id)
 	if err != nil {
 		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 	if account == nil {
-		return fmt.Errorf("tài khoản không tồn tại")
+		return ErrAccountNotFound
+	}
+	if err := checkRoleRank(actor, account.Role); err != nil {
+		return err
 	}
 
-	if err := s.accountRepository.Update(
-		ctx, 
-		map[string]interface{}{"id": id},
-		map[string]interface{}{"account_status": false},
-	); err != nil {
-		return fmt.Errorf("lỗi khi khóa tài khoản: %w", err)
+	if lockRequest.LockedUntil != nil && !lockRequest.LockedUntil.After(time.Now()) {
+		return ErrInvalidLockedUntil
 	}
 
-	return nil
+	var reason *string
+	if lockRequest.Reason != "" {
+		reason = &lockRequest.Reason
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": id},
+			map[string]interface{}{
+				"account_status": false,
+				"lock_reason":    reason,
+				"locked_until":   lockRequest.LockedUntil,
+			},
+		); err != nil {
+			return fmt.Errorf("lỗi khi khóa tài khoản: %w", err)
+		}
+
+		return s.recordStatusChange(ctx, account, actor.UserID, false, reason, lockRequest.LockedUntil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.dropAccountState(ctx, account)
 }
 
-func(s *UserServiceImpl) UnlockAccount(ctx context.Context, id string) error {
+func(s *UserServiceImpl) UnlockAccount(ctx context.Context, id string, actor Actor) error {
 	account, err := s.accountRepository.GetAccountById(ctx, id)
 	if err != nil {
 		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
 	}
 
 	if account == nil {
-		return fmt.Errorf("tài khoản không tồn tại")
+		return ErrAccountNotFound
 	}
 
-	if err := s.accountRepository.Update(
-		ctx, 
-		map[string]interface{}{"id": id},
-		map[string]interface{}{"account_status": true},
-	); err != nil {
-		return fmt.Errorf("lỗi khi mở khóa tài khoản: %w", err)
+	if err := checkRoleRank(actor, account.Role); err != nil {
+		return err
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.accountRepository.Update(
+			ctx,
+			map[string]interface{}{"id": id},
+			map[string]interface{}{
+				"account_status": true,
+				"lock_reason":    nil,
+				"locked_until":   nil,
+			},
+		); err != nil {
+			return fmt.Errorf("lỗi khi mở khóa tài khoản: %w", err)
+		}
+
+		return s.recordStatusChange(ctx, account, actor.UserID, true, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.dropAccountState(ctx, account)
+}
+
+func(s *UserServiceImpl) GetAccountStatusHistory(ctx context.Context, id string) ([]*models.AccountStatusHistory, error) {
+	account, err := s.accountRepository.GetAccountById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	histories, err := s.historyRepository.GetListByAccountId(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi lấy lịch sử trạng thái tài khoản: %w", err)
+	}
+
+	return histories, nil
+}
+
+// ClearLoginLockout resets the failed-login counter of the account and lifts
+// the temporary login lock it caused.
+func(s *UserServiceImpl) ClearLoginLockout(ctx context.Context, id string, actor Actor) error {
+	account, err := s.accountRepository.GetAccountById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("lỗi khi lấy tài khoản: %w", err)
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if err := checkRoleRank(actor, account.Role); err != nil {
+		return err
+	}
+
+	if err := s.redisStore.ClearLoginFailures(ctx, loginAccountKey(account.Email)); err != nil {
+		return fmt.Errorf("lỗi khi xóa khóa đăng nhập: %w", err)
+	}
+
+	return nil
+}
+
+// recordStatusChange writes the history row of a lock or an unlock.
+func(s *UserServiceImpl) recordStatusChange(
+	ctx context.Context,
+	account *models.Account,
+	changedBy string,
+	status bool,
+	reason *string,
+	lockedUntil *time.Time,
+) error {
+	history := &models.AccountStatusHistory{
+		AccountID:     account.ID,
+		AccountStatus: status,
+		Reason:        reason,
+		LockedUntil:   lockedUntil,
+	}
+
+	if changedByID, err := uuid.Parse(changedBy); err == nil {
+		history.ChangedBy = &changedByID
+	}
+
+	if err := s.historyRepository.Create(ctx, history); err != nil {
+		return fmt.Errorf("lỗi khi lưu lịch sử trạng thái tài khoản: %w", err)
+	}
+
+	return nil
+}
+
+// dropAccountState drops the cached account state so JWTAuthMiddleware sees
+// a change of lock or role on the next request. It runs once the change is
+// committed: a request reading the account before that would cache the old
+// state again.
+func(s *UserServiceImpl) dropAccountState(ctx context.Context, account *models.Account) error {
+	if err := s.redisStore.DeleteAccountState(ctx, account.ID.String()); err != nil {
+		return fmt.Errorf("lỗi khi xóa bộ nhớ đệm trạng thái tài khoản: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// checkAssignableRole fails unless role is a role of the roles table that
+// does not outrank the role of actor.
+func(s *UserServiceImpl) checkAssignableRole(ctx context.Context, actor Actor, role string) error {
+	found, err := s.roleRepository.GetRole(ctx, role)
+	if err != nil {
+		return fmt.Errorf("lỗi khi lấy vai trò: %w", err)
+	}
+
+	if found == nil {
+		return ErrUnknownRole
+	}
+
+	return checkRoleRank(actor, role)
+}
@@ -4,10 +4,14 @@ import (
 	"DH52111659-api-quan-ly-suc-khoe/config"
 	_ "DH52111659-api-quan-ly-suc-khoe/docs"
 	"DH52111659-api-quan-ly-suc-khoe/internal/handlers"
+	"DH52111659-api-quan-ly-suc-khoe/internal/mailer"
 	"DH52111659-api-quan-ly-suc-khoe/internal/middleware"
+	"DH52111659-api-quan-ly-suc-khoe/internal/models"
 	"DH52111659-api-quan-ly-suc-khoe/internal/repositories"
 	"DH52111659-api-quan-ly-suc-khoe/internal/services"
 	"DH52111659-api-quan-ly-suc-khoe/utils"
+	"context"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -25,7 +29,18 @@ import (
 func main() {
 	config.LoadConfig()
 	repositories.ConnectDB()
-	redis, err := repositories.NewRedisStore()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if err := config.AppConfig.Validate(); err != nil {
+		panic(err)
+	}
+	if config.AppConfig.DBAutoMigrate {
+		if err := migrateUp(); err != nil {
+			panic(err)
+		}
+	}
+	redis, err := repositories.NewStore()
 	if err != nil {
 		panic(err)
 	}
@@ -33,26 +48,86 @@ func main() {
 	// 3. Khởi tạo router
 	gin.SetMode(gin.DebugMode)
 	router := gin.Default()
+	// The client IP keys the login lockout and is recorded on sessions, it is
+	// only read from X-Forwarded-For when a trusted proxy sets it
+	if err := router.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		panic(err)
+	}
 	router.Use(gin.Logger()) // Log requests
 	router.Use(gin.Recovery()) // Recover from panics and log them
+	router.Use(middleware.ErrorHandler()) // Answer the errors handlers report with ctx.Error
 
 	// 4. Khởi tạo các service và handler
+	tokenService := utils.NewTokenService(config.AppConfig.SECRET_KEY)
+	if config.AppConfig.JWTKeyDir != "" {
+		tokenService, err = utils.NewTokenServiceWithKeyDir(
+			config.AppConfig.SECRET_KEY,
+			config.AppConfig.JWTKeyDir,
+			config.AppConfig.JWTSigningKeyID,
+			config.AppConfig.JWTAllowHS256,
+		)
+		if err != nil {
+			panic(err)
+		}
+		go tokenService.WatchKeyDir(config.AppConfig.JWTKeyReloadInterval)
+	}
+	jwksHandler := handlers.NewJWKSHandler(tokenService)
+
+	mailRenderer, err := mailer.NewRenderer()
+	if err != nil {
+		panic(err)
+	}
+	emailOutboxRepo := repositories.NewEmailOutboxRepoImpl(repositories.DB)
+	mailService := services.NewMailServiceImpl(emailOutboxRepo)
+	if config.AppConfig.EmailWorkerEnabled {
+		emailWorker := services.NewEmailOutboxWorker(
+			emailOutboxRepo,
+			newMailer(),
+			mailRenderer,
+			config.AppConfig.EmailWorkerInterval,
+			config.AppConfig.EmailMaxAttempts,
+		)
+		go emailWorker.Run(context.Background())
+	}
+	emailOutboxService := services.NewEmailOutboxServiceImpl(emailOutboxRepo)
+	emailOutboxHandler := handlers.NewEmailOutboxHandler(emailOutboxService)
+
+	txManager := repositories.NewTxManagerImpl(repositories.DB)
 	accountRepo := repositories.NewAccountRepoImpl(repositories.DB)
-	authService := services.NewAuthServiceImpl(accountRepo, redis)
+	sessionRepo := repositories.NewSessionRepoImpl(repositories.DB)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepoImpl(repositories.DB)
+	mfaService := services.NewMFAServiceImpl(accountRepo, recoveryCodeRepo, redis, mailService, txManager)
+	authService := services.NewAuthServiceImpl(accountRepo, sessionRepo, redis, mfaService, mailService, tokenService, txManager)
 	authHandler := handlers.NewAuthHandler(authService)
+	mfaHandler := handlers.NewMFAHandler(authService, mfaService)
+	sessionService := services.NewSessionServiceImpl(sessionRepo, accountRepo, redis)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
 
 	profileRepo := repositories.NewProfileRepoImpl(repositories.DB)
 	profileService := services.NewProfileServiceImpl(profileRepo)
 	profileHandler := handlers.NewProfileHandler(profileService)
+	accessGrantRepo := repositories.NewAccessGrantRepoImpl(repositories.DB)
+	accessGrantService := services.NewAccessGrantServiceImpl(accessGrantRepo, accountRepo)
+	accessGrantHandler := handlers.NewAccessGrantHandler(accessGrantService)
 
-	userService := services.NewUserServiceImpl(accountRepo)
+	accountStatusHistoryRepo := repositories.NewAccountStatusHistoryRepoImpl(repositories.DB)
+	roleRepo := repositories.NewRoleRepoImpl(repositories.DB)
+	userService := services.NewUserServiceImpl(accountRepo, accountStatusHistoryRepo, roleRepo, redis, txManager)
 	userHandler := handlers.NewUserHandler(userService)
+	userImportService := services.NewUserImportServiceImpl(accountRepo, profileRepo, mailService, txManager)
+	userImportHandler := handlers.NewUserImportHandler(userImportService)
+	userExportService := services.NewUserExportServiceImpl(accountRepo)
+	userExportHandler := handlers.NewUserExportHandler(userExportService)
 
 	expertRepo := repositories.NewExpertRepositoryImpl(repositories.DB)
-	expertService := services.NewExpertService(expertRepo)
+	expertService := services.NewExpertService(expertRepo, accountRepo, mailService, txManager)
 	expertHandler := handlers.NewExpertHandler(expertService)
+
+	authorizationService := services.NewAuthorizationServiceImpl(roleRepo, txManager)
+	roleHandler := handlers.NewRoleHandler(authorizationService)
+	accessPolicy := services.NewAccessPolicyImpl(authorizationService, accessGrantRepo)
 	// 5. Đăng ký các route
-	registerRouter(router, authHandler, profileHandler, userHandler, expertHandler)
+	registerRouter(router, authService, authorizationService, accessPolicy, jwksHandler, authHandler, mfaHandler, sessionHandler, profileHandler, accessGrantHandler, userHandler, userImportHandler, userExportHandler, expertHandler, emailOutboxHandler, roleHandler)
 
 	// 6. Khởi động server
 	if err := router.Run(":"+config.AppConfig.GinPort); err != nil {
@@ -60,15 +135,40 @@ func main() {
 	}
 }
 
+// newMailer picks the mail transport from MAIL_DRIVER.
+func newMailer() mailer.Mailer {
+	c := config.AppConfig
+	switch c.MailDriver {
+	case "file":
+		return mailer.NewFileMailer(c.MailDir, c.MailFrom)
+	case "memory":
+		return mailer.NewMemoryMailer()
+	default:
+		return mailer.NewSMTPMailer(c.SMTPHost, c.SMTPPort, c.SenderEmail, c.SenderPass, c.MailFrom)
+	}
+}
+
 func registerRouter(
 	router *gin.Engine, 
+	authService services.AuthService,
+	authorizationService services.AuthorizationService,
+	accessPolicy services.AccessPolicy,
+	jwksHandler *handlers.JWKSHandler,
 	accountHandler *handlers.AuthHandler,
+	mfaHandler *handlers.MFAHandler,
+	sessionHandler *handlers.SessionHandler,
 	profileHandler *handlers.ProfileHandler,
+	accessGrantHandler *handlers.AccessGrantHandler,
 	userHandler *handlers.UserHandler,
+	userImportHandler *handlers.UserImportHandler,
+	userExportHandler *handlers.UserExportHandler,
 	expertHandler *handlers.ExpertHandler,
+	emailOutboxHandler *handlers.EmailOutboxHandler,
+	roleHandler *handlers.RoleHandler,
 	) {
 	// Tạo một nhóm router cho API
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKSHandler)
 
 	api := router.Group("/api/v1")
 	{
@@ -80,47 +180,105 @@ func registerRouter(
 			{
 				public.POST("/register", accountHandler.RegisterAccountHandler)
 				public.POST("/verify-email", accountHandler.RegisterVerifyOTPHandler)
+				public.POST("/otp/resend", accountHandler.ResendOTPHandler)
 				public.POST("/login", accountHandler.LoginHandler)
 				public.POST("/token/refresh", accountHandler.RefreshTokenHandler)
 				public.POST("/password/forgot", accountHandler.ForgotPasswordHandler)
 				public.POST("/password/verify-otp", accountHandler.VerifyOTPHandler)
 				public.POST("/password/reset", accountHandler.ResetPasswordHandler)
+				public.POST("/mfa/verify", mfaHandler.VerifyMFAHandler)
+				public.POST("/mfa/enroll", mfaHandler.EnrollMFAHandler)
 			}
 	
 			protected := authGroup.Group("")
 			{
-				protected.Use(middleware.JWTAuthMiddleware(*utils.NewTokenService(config.AppConfig.SECRET_KEY)))
+				protected.Use(middleware.JWTAuthMiddleware(authService))
 				protected.POST("/password/change", accountHandler.ChangePasswordHandler)
+				protected.PATCH("/locale", accountHandler.ChangeLocaleHandler)
+				protected.POST("/logout", accountHandler.LogoutHandler)
+				protected.POST("/logout-all", accountHandler.LogoutAllHandler)
+				protected.POST("/mfa/totp/setup", mfaHandler.SetupTOTPHandler)
+				protected.POST("/mfa/totp/confirm", mfaHandler.ConfirmTOTPHandler)
+				protected.POST("/mfa/totp/disable", mfaHandler.DisableTOTPHandler)
+				protected.GET("/sessions", sessionHandler.ListMySessionsHandler)
+				protected.DELETE("/sessions/:id", sessionHandler.RevokeMySessionHandler)
 			}		
 		}
 
+		// Every admin route names the permission it needs
+		can := func(permission string) gin.HandlerFunc {
+			return middleware.RequirePermission(authorizationService, permission)
+		}
+		// Routes on the resources of the account in :id check its ownership
+		owner := func(resource services.OwnedResource, action services.AccessAction) gin.HandlerFunc {
+			return middleware.RequireOwnerAccess(accessPolicy, resource, action, "id")
+		}
+
 		profileGroup := api.Group("/profile")
 		{
 			protected := profileGroup.Group("")
 			{
-				protected.Use(middleware.JWTAuthMiddleware(*utils.NewTokenService(config.AppConfig.SECRET_KEY)))
+				protected.Use(middleware.JWTAuthMiddleware(authService))
 				protected.POST("",profileHandler.CreateProfileHandler)
-				protected.PUT(":id", profileHandler.UpdateProfileHandler)
+				protected.GET("/me", profileHandler.GetMyProfileHandler)
+				protected.PUT("/me", profileHandler.UpdateMyProfileHandler)
+				protected.PATCH("/me", profileHandler.PatchMyProfileHandler)
+				protected.DELETE("/me", profileHandler.DeleteMyProfileHandler)
+				protected.DELETE("/me/avatar", profileHandler.RemoveMyAvatarHandler)
+				protected.GET("/me/access-grants", accessGrantHandler.GetListAccessGrantHandler)
+				protected.POST("/me/access-grants", accessGrantHandler.GrantAccessHandler)
+				protected.DELETE("/me/access-grants/:expertId", accessGrantHandler.RevokeAccessHandler)
+				protected.GET("/:id", owner(services.ProfileResource, services.AccessRead), profileHandler.GetProfileHandler)
+				protected.PUT("/:id", owner(services.ProfileResource, services.AccessUpdate), profileHandler.UpdateProfileHandler)
+				protected.PATCH("/:id", owner(services.ProfileResource, services.AccessUpdate), profileHandler.PatchProfileHandler)
+				protected.DELETE("/:id", owner(services.ProfileResource, services.AccessUpdate), profileHandler.DeleteProfileHandler)
+				protected.DELETE("/:id/avatar", owner(services.ProfileResource, services.AccessUpdate), profileHandler.RemoveAvatarHandler)
 			}
 		}
 
 		adminGroup := api.Group("/admin")
 		{
-			adminGroup.Use(middleware.JWTAuthMiddleware(*utils.NewTokenService(config.AppConfig.SECRET_KEY), "admin"))
+			adminGroup.Use(middleware.JWTAuthMiddleware(authService))
 			userGroup := adminGroup.Group("")
 			{
-				userGroup.POST("/user", userHandler.CreateUserHandler)
-				userGroup.POST("/user/reset-password", userHandler.ResetPasswordUserHandler)
-				userGroup.GET("/users", userHandler.GetListUserHandler)
-				userGroup.GET("/user/:id", userHandler.GetUserByIdHandler)
-				userGroup.PATCH("/user/:id/lock", userHandler.LockUserAccountHandler)
-				userGroup.PATCH("/user/:id/unlock", userHandler.UnlockUserAccountHandler)
+				userGroup.POST("/user", can(models.PermissionUserCreate), userHandler.CreateUserHandler)
+				userGroup.POST("/user/reset-password", can(models.PermissionUserResetPassword), userHandler.ResetPasswordUserHandler)
+				userGroup.GET("/users", can(models.PermissionUserRead), userHandler.GetListUserHandler)
+				userGroup.POST("/users/import", can(models.PermissionUserImport), userImportHandler.ImportUsersHandler)
+				userGroup.GET("/users/export", can(models.PermissionUserExport), userExportHandler.ExportUsersHandler)
+				userGroup.GET("/user/:id", can(models.PermissionUserRead), userHandler.GetUserByIdHandler)
+				userGroup.PATCH("/user/:id/lock", can(models.PermissionUserLock), userHandler.LockUserAccountHandler)
+				userGroup.PATCH("/user/:id/unlock", can(models.PermissionUserLock), userHandler.UnlockUserAccountHandler)
+				userGroup.GET("/user/:id/status-history", can(models.PermissionUserRead), userHandler.GetUserStatusHistoryHandler)
+				userGroup.DELETE("/user/:id/login-lockout", can(models.PermissionUserLock), userHandler.ClearUserLoginLockoutHandler)
+				userGroup.GET("/user/:id/sessions", can(models.PermissionSessionReadAny), sessionHandler.ListUserSessionsHandler)
+				userGroup.DELETE("/user/:id/sessions/:sessionId", can(models.PermissionSessionRevokeAny), sessionHandler.RevokeUserSessionHandler)
+			}
+
+			profileGroup := adminGroup.Group("/profiles")
+			{
+				profileGroup.GET("/:id", can(models.PermissionProfileReadAny), profileHandler.GetProfileByIdHandler)
 			}
 
 			expertGroup := adminGroup.Group("")
 			{
-				expertGroup.POST("/expert",expertHandler.CreateExpertHandler)
+				expertGroup.POST("/expert", can(models.PermissionExpertCreate), expertHandler.CreateExpertHandler)
+			}
+
+			emailGroup := adminGroup.Group("/emails")
+			{
+				emailGroup.GET("", can(models.PermissionEmailRead), emailOutboxHandler.GetListEmailHandler)
+				emailGroup.GET("/:id", can(models.PermissionEmailRead), emailOutboxHandler.GetEmailByIdHandler)
+				emailGroup.POST("/:id/retry", can(models.PermissionEmailRetry), emailOutboxHandler.RetryEmailHandler)
+			}
+
+			roleGroup := adminGroup.Group("")
+			{
+				roleGroup.Use(can(models.PermissionRoleManage))
+				roleGroup.GET("/roles", roleHandler.GetListRoleHandler)
+				roleGroup.GET("/permissions", roleHandler.GetListPermissionHandler)
+				roleGroup.PUT("/roles/:role/permissions", roleHandler.UpdateRolePermissionsHandler)
 			}
 		}
 	}
-}
\ No newline at end of file
+}
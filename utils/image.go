@@ -7,9 +7,9 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 const maxFileSize = 10 << 20 
 const uploads = "/uploads/"
@@ -39,7 +39,7 @@ func HandleFileUpload(ctx *gin.Context, formFieldName, uploadDir string) (string
 	}
 
 	// Generate unique filename
-	filename := generateFileNameUpload(filepath.Base(file.Filename), fileExt)
+	filename := generateFileNameUpload(fileExt)
 
 	  // Create upload directory if not exists
 	if _, err := createUploadDir(filepath.Join(uploadDir,avatar)); err != nil {
@@ -83,10 +83,10 @@ func HandleFileDeleted(fileName string, uploadDir string) error {
     return nil
 }
 
-func generateFileNameUpload(fileName, fileExt string) string {
-	nameWithoutExt := strings.TrimSuffix(fileName, fileExt)
-	timestamp := time.Now().Format("20060102_150405")
-	return fmt.Sprintf("%s_%s%s", nameWithoutExt, timestamp, fileExt)
+// generateFileNameUpload names an upload with a random UUID, so two uploads
+// never share a file even when they have the same name.
+func generateFileNameUpload(fileExt string) string {
+	return uuid.NewString() + fileExt
 }
 
 func generateAvatarURL(ctx *gin.Context, fileName string) string{
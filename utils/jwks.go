@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+// signingKey is one key of the key directory. The file name without its
+// suffix is the kid. Public-only keys are published and verified but never
+// used for signing.
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the body of /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// loadKeyDir reads every RSA or Ed25519 key of dir.
+// <kid>.pem holds a private key (PKCS#8 or PKCS#1) and <kid>.pub.pem a
+// public key (PKIX) that is only used to verify tokens.
+func loadKeyDir(dir string) (map[string]*signingKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("đọc thư mục khóa JWT thất bại: %w", err)
+	}
+
+	keys := make(map[string]*signingKey)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("đọc khóa JWT %s thất bại: %w", name, err)
+		}
+
+		var key *signingKey
+		if strings.HasSuffix(name, publicKeySuffix) {
+			key, err = parsePublicKey(data)
+			name = strings.TrimSuffix(name, publicKeySuffix)
+		} else {
+			key, err = parsePrivateKey(data)
+			name = strings.TrimSuffix(name, privateKeySuffix)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("khóa JWT %s không hợp lệ: %w", entry.Name(), err)
+		}
+
+		// A private key wins over the public copy of the same kid
+		if existing, ok := keys[name]; ok && existing.private != nil {
+			continue
+		}
+		key.kid = name
+		keys[name] = key
+	}
+
+	return keys, nil
+}
+
+func parsePrivateKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("không tìm thấy khối PEM")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("loại khối PEM %q không được hỗ trợ", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{method: jwt.SigningMethodEdDSA, private: private, public: private.Public()}, nil
+	default:
+		return nil, errors.New("chỉ hỗ trợ khóa RSA và Ed25519")
+	}
+}
+
+func parsePublicKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("không tìm thấy khối PEM")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("loại khối PEM %q không được hỗ trợ", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		return &signingKey{method: jwt.SigningMethodRS256, public: public}, nil
+	case ed25519.PublicKey:
+		return &signingKey{method: jwt.SigningMethodEdDSA, public: public}, nil
+	default:
+		return nil, errors.New("chỉ hỗ trợ khóa RSA và Ed25519")
+	}
+}
+
+// pickSigningKey returns the pinned kid when set, otherwise the private key
+// with the greatest kid, so naming keys by date makes the newest one active.
+func pickSigningKey(keys map[string]*signingKey, pinned string) (*signingKey, error) {
+	if pinned != "" {
+		key, ok := keys[pinned]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("không tìm thấy khóa bí mật cho kid %q", pinned)
+		}
+		return key, nil
+	}
+
+	var active *signingKey
+	for _, key := range keys {
+		if key.private != nil && (active == nil || key.kid > active.kid) {
+			active = key
+		}
+	}
+	if active == nil {
+		return nil, errors.New("thư mục khóa JWT không có khóa bí mật nào")
+	}
+	return active, nil
+}
+
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Use: "sig", Alg: k.method.Alg(), Kid: k.kid}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+func sortedJWKs(keys map[string]*signingKey) []JWK {
+	jwks := make([]JWK, 0, len(keys))
+	for _, key := range keys {
+		jwks = append(jwks, key.jwk())
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
@@ -2,14 +2,38 @@ package utils
 
 import (
 	"DH52111659-api-quan-ly-suc-khoe/internal/models"
+	"errors"
+	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+
+	AccessTokenTTL  = time.Hour * 24
+	RefreshTokenTTL = time.Hour * 24 * 7
+)
+
+// TokenService signs with the active key of KeyDir (RS256 or EdDSA, with a
+// kid header) and verifies with any key of the directory. Without KeyDir it
+// falls back to HS256 with SecretKey.
 type TokenService struct {
-	SecretKey string
+	SecretKey    string
+	KeyDir       string
+	SigningKeyID string
+	// AllowHS256 keeps tokens signed with SecretKey valid after switching
+	// to KeyDir, until the last of them expires.
+	AllowHS256 bool
+
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active *signingKey
 }
 
 func NewTokenService(secretKey string) *TokenService {
@@ -18,35 +42,189 @@ func NewTokenService(secretKey string) *TokenService {
 	}
 }
 
+// NewTokenServiceWithKeyDir loads the keys of keyDir. signingKeyID pins the
+// signing key, otherwise the private key with the greatest kid is used.
+func NewTokenServiceWithKeyDir(secretKey, keyDir, signingKeyID string, allowHS256 bool) (*TokenService, error) {
+	t := &TokenService{
+		SecretKey:    secretKey,
+		KeyDir:       keyDir,
+		SigningKeyID: signingKeyID,
+		AllowHS256:   allowHS256,
+	}
+	if err := t.ReloadKeys(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ReloadKeys reads KeyDir again. On error the current keys stay in use.
+//
+// To rotate without downtime, first add the new key as <kid>.pub.pem so it
+// shows up in the JWKS, then once verifiers have refreshed replace it with
+// the private <kid>.pem; the old private key keeps verifying until its last
+// refresh token has expired and can then be removed.
+func (t *TokenService) ReloadKeys() error {
+	if t.KeyDir == "" {
+		return nil
+	}
+
+	keys, err := loadKeyDir(t.KeyDir)
+	if err != nil {
+		return err
+	}
+
+	active, err := pickSigningKey(keys, t.SigningKeyID)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.keys = keys
+	t.active = active
+	t.mu.Unlock()
+	return nil
+}
+
+// WatchKeyDir reloads KeyDir every interval so added or removed keys are
+// picked up without a restart.
+func (t *TokenService) WatchKeyDir(interval time.Duration) {
+	if t.KeyDir == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := t.ReloadKeys(); err != nil {
+			log.Printf("Reload JWT keys failed: %v", err)
+		}
+	}
+}
+
+// JWKS returns the public keys that verify our tokens. It is empty in
+// HS256 mode since a shared secret must never be published.
+func (t *TokenService) JWKS() JWKSet {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return JWKSet{Keys: sortedJWKs(t.keys)}
+}
+
+func (t *TokenService) sign(claims TokenClaims, key *signingKey) (string, error) {
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(t.SecretKey))
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+func (t *TokenService) signingKey() *signingKey {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.active
+}
+
+// verificationKey looks up the key by the kid header and refuses any other
+// algorithm than the one of that key.
+func (t *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if t.KeyDir != "" && !t.AllowHS256 {
+			return nil, errors.New("token không có kid")
+		}
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() || t.SecretKey == "" {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return []byte(t.SecretKey), nil
+	}
+
+	key, ok := t.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("không tìm thấy khóa cho kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// TokenClaims is shared by access and refresh tokens.
+// RegisteredClaims.ID holds the jti of the token and FamilyID links every
+// refresh token issued from the same login, so a whole chain can be revoked.
 type TokenClaims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role,omitempty"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role,omitempty"`
+	TokenType string `json:"typ,omitempty"`
+	FamilyID  string `json:"fid,omitempty"`
+	// IssuedAtMs is iat in milliseconds. It is compared with the time of a
+	// logout-all, which may fall in the same second as a new login.
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (t *TokenService) GenerateTokens(user models.Account) (accessToken, refreshToken string, err error) {
+// IssuedAtTime is the issue time of the token, to the millisecond when the
+// token carries iat_ms. It is the zero time for a token without iat.
+func (c *TokenClaims) IssuedAtTime() time.Time {
+	if c.IssuedAtMs != 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// TokenPair is the result of GenerateTokens.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshID        string
+	FamilyID         string
+	RefreshExpiresAt time.Time
+}
+
+// GenerateTokens issues an access/refresh token pair for the user.
+// An empty familyID starts a new refresh token family (a new login),
+// otherwise the pair continues the given family (a rotation).
+func (t *TokenService) GenerateTokens(user models.Account, familyID string) (*TokenPair, error) {
     var wg sync.WaitGroup
     var errAccess, errRefresh error
     accessChan := make(chan string, 1)
     refreshChan := make(chan string, 1)
 
+    if familyID == "" {
+        familyID = uuid.NewString()
+    }
+    now := time.Now()
+    refreshID := uuid.NewString()
+    refreshExpiresAt := now.Add(RefreshTokenTTL)
+    // Both tokens use the same key even if a reload happens in between
+    key := t.signingKey()
+
     wg.Add(2)
 
     // Goroutine để tạo access token
     go func() {
         defer wg.Done()
         accessClaim := TokenClaims{
-            UserID: user.ID.String(),
-            Role:   user.Role,
+            UserID:     user.ID.String(),
+            Role:       user.Role,
+            TokenType:  AccessTokenType,
+            FamilyID:   familyID,
+            IssuedAtMs: now.UnixMilli(),
             RegisteredClaims: jwt.RegisteredClaims{
-                ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
-                IssuedAt:  jwt.NewNumericDate(time.Now()),
+                ID:        uuid.NewString(),
+                ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+                IssuedAt:  jwt.NewNumericDate(now),
                 Subject:   user.ID.String(),
             },
         }
 
-        accessJwt := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaim)
-        token, err := accessJwt.SignedString([]byte(t.SecretKey))
+        token, err := t.sign(accessClaim, key)
         if err != nil {
             errAccess = err
         } else {
@@ -58,17 +236,20 @@ func (t *TokenService) GenerateTokens(user models.Account) (accessToken, refresh
     go func() {
         defer wg.Done()
         refreshClaim := TokenClaims{
-            UserID: user.ID.String(),
-            Role:   user.Role,
+            UserID:     user.ID.String(),
+            Role:       user.Role,
+            TokenType:  RefreshTokenType,
+            FamilyID:   familyID,
+            IssuedAtMs: now.UnixMilli(),
             RegisteredClaims: jwt.RegisteredClaims{
-                ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)),
-                IssuedAt:  jwt.NewNumericDate(time.Now()),
+                ID:        refreshID,
+                ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
+                IssuedAt:  jwt.NewNumericDate(now),
                 Subject:   user.ID.String(),
             },
         }
 
-        refreshJwt := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaim)
-        token, err := refreshJwt.SignedString([]byte(t.SecretKey))
+        token, err := t.sign(refreshClaim, key)
         if err != nil {
             errRefresh = err
         } else {
@@ -83,20 +264,28 @@ func (t *TokenService) GenerateTokens(user models.Account) (accessToken, refresh
 
     // Lấy kết quả từ channels
     if errAccess != nil {
-        return "", "", errAccess
+        return nil, errAccess
     }
     if errRefresh != nil {
-        return "", "", errRefresh
+        return nil, errRefresh
     }
 
-    return <-accessChan, <-refreshChan, nil
+    return &TokenPair{
+        AccessToken:      <-accessChan,
+        RefreshToken:     <-refreshChan,
+        RefreshID:        refreshID,
+        FamilyID:         familyID,
+        RefreshExpiresAt: refreshExpiresAt,
+    }, nil
 }
 
 func (s *TokenService) VerifyToken(tokenString string) (*TokenClaims, error) {
 	claims := &TokenClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.SecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.verificationKey, jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+		jwt.SigningMethodHS256.Alg(),
+	}))
 	if err != nil {
 		return nil, err
 	}